@@ -5,7 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Spriithy/rosa/pkg/compiler"
-	"github.com/Spriithy/rosa/pkg/compiler/text"
+	"github.com/Spriithy/rosa/pkg/compiler/ast"
 	"github.com/urfave/cli"
 )
 
@@ -27,17 +27,13 @@ func buildAction(c *cli.Context) (err error) {
 		return
 	}
 
-	file := c.Args().First()
-	/*p := compiler.NewParser(file)
-	result := p.Parse()
-	tree := result.Accept(ast.AstPrinter{})
-	fmt.Println(tree)
-	*/
-	s := compiler.NewScanner(file)
-	for token := s.Scan(); !text.Eof(token); token = s.Scan() {
-		fmt.Println(token.String())
+	p, err := compiler.OpenParser(c.Args().First())
+	if err != nil {
+		return
 	}
-	for _, log := range s.Logs {
+	result, logs := p.Parse()
+	fmt.Println(result.Accept(ast.AstPrinter{}))
+	for _, log := range logs {
 		fmt.Println(log.AsError())
 	}
 	return
@@ -10,9 +10,11 @@ type AST interface {
 ////////////////////////////////////////////////////////////////////////////////
 
 type ModuleAST struct {
-	Name   string
-	Tokens []text.Token
-	Decls  []*DeclAST
+	Name    string
+	Path    []string
+	Tokens  []text.Token
+	Imports []*ImportDecl
+	Decls   []Decl
 }
 
 func (*ModuleAST) ast()                               {}
@@ -20,11 +22,151 @@ func (m *ModuleAST) Accept(printer AstPrinter) string { return printer.visitModu
 
 ////////////////////////////////////////////////////////////////////////////////
 
+type ImportDecl struct {
+	Token     text.Token
+	Path      []text.Token
+	Alias     text.Token
+	Selectors []text.Token
+}
+
+func (*ImportDecl) ast()                               {}
+func (d *ImportDecl) Accept(printer AstPrinter) string { return printer.visitImportDecl(d) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type Decl interface {
+	decl()
+	AST
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
 type DeclAST struct {
-	Name   string
-	Tokens []text.Token
-	Expr   Expr
+	Name       string
+	Tokens     []text.Token
+	TypeParams []*TypeParamAST
+	Params     [][]*ParamAST
+	ReturnType Type
+	Expr       Expr
 }
 
 func (*DeclAST) ast()                               {}
+func (*DeclAST) decl()                              {}
 func (d *DeclAST) Accept(printer AstPrinter) string { return printer.visitDeclAST(d) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type TypeParamAST struct {
+	Name        text.Token
+	Constraints []Type
+}
+
+func (*TypeParamAST) ast()                               {}
+func (a *TypeParamAST) Accept(printer AstPrinter) string { return printer.visitTypeParamAST(a) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// A placeholder for a declaration that failed to parse, spanning the tokens
+// skipped during recovery
+type BadDecl struct {
+	From text.Token
+	To   text.Token
+}
+
+func (*BadDecl) ast()                               {}
+func (*BadDecl) decl()                              {}
+func (d *BadDecl) Accept(printer AstPrinter) string { return printer.visitBadDecl(d) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type ParamAST struct {
+	Name text.Token
+	Type Type
+}
+
+func (*ParamAST) ast()                               {}
+func (a *ParamAST) Accept(printer AstPrinter) string { return printer.visitParamAST(a) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type StructDecl struct {
+	Token      text.Token
+	Name       text.Token
+	TypeParams []*TypeParamAST
+	Fields     []*FieldAST
+}
+
+func (*StructDecl) ast()                               {}
+func (*StructDecl) decl()                              {}
+func (d *StructDecl) Accept(printer AstPrinter) string { return printer.visitStructDecl(d) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type FieldAST struct {
+	Name text.Token
+	Type Type
+}
+
+func (*FieldAST) ast()                               {}
+func (f *FieldAST) Accept(printer AstPrinter) string { return printer.visitFieldAST(f) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type TraitDecl struct {
+	Token      text.Token
+	Name       text.Token
+	TypeParams []*TypeParamAST
+	Methods    []*DeclAST
+}
+
+func (*TraitDecl) ast()                               {}
+func (*TraitDecl) decl()                              {}
+func (d *TraitDecl) Accept(printer AstPrinter) string { return printer.visitTraitDecl(d) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type ImplDecl struct {
+	Token      text.Token
+	TypeParams []*TypeParamAST
+	Trait      Type
+	Methods    []*DeclAST
+}
+
+func (*ImplDecl) ast()                               {}
+func (*ImplDecl) decl()                              {}
+func (d *ImplDecl) Accept(printer AstPrinter) string { return printer.visitImplDecl(d) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type TypeDecl struct {
+	Token        text.Token
+	Name         text.Token
+	TypeParams   []*TypeParamAST
+	Constructors []*ConstructorAST
+}
+
+func (*TypeDecl) ast()                               {}
+func (*TypeDecl) decl()                              {}
+func (d *TypeDecl) Accept(printer AstPrinter) string { return printer.visitTypeDecl(d) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type ConstructorAST struct {
+	Name   text.Token
+	Fields []Type
+}
+
+func (*ConstructorAST) ast()                               {}
+func (c *ConstructorAST) Accept(printer AstPrinter) string { return printer.visitConstructorAST(c) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type FixityDecl struct {
+	Token      text.Token
+	Precedence int
+	Operators  []text.Token
+}
+
+func (*FixityDecl) ast()                               {}
+func (*FixityDecl) decl()                              {}
+func (d *FixityDecl) Accept(printer AstPrinter) string { return printer.visitFixityDecl(d) }
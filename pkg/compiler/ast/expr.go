@@ -29,21 +29,163 @@ func (p AstPrinter) parenthesize(name string, asts ...AST) string {
 }
 
 func (p AstPrinter) visitModuleAST(ast *ModuleAST) string {
-	asts := make([]AST, len(ast.Decls))
+	asts := make([]AST, 0, len(ast.Imports)+len(ast.Decls))
+	for i := range ast.Imports {
+		asts = append(asts, ast.Imports[i])
+	}
 	for i := range ast.Decls {
-		asts[i] = ast.Decls[i]
+		asts = append(asts, ast.Decls[i])
 	}
 	return p.parenthesize("module "+ast.Name+"\n", asts...)
 }
 
+func (p AstPrinter) visitImportDecl(ast *ImportDecl) string {
+	path := make([]string, len(ast.Path))
+	for i, name := range ast.Path {
+		path[i] = name.Text
+	}
+	s := "(import " + strings.Join(path, ".")
+	if ast.Selectors != nil {
+		selectors := make([]string, len(ast.Selectors))
+		for i, name := range ast.Selectors {
+			selectors[i] = name.Text
+		}
+		s += ".{" + strings.Join(selectors, ", ") + "}"
+	}
+	if ast.Alias.Text != "" {
+		s += " as " + ast.Alias.Text
+	}
+	return s + ")\n"
+}
+
 func (p AstPrinter) visitDeclAST(ast *DeclAST) string {
-	return p.parenthesize("decl "+ast.Name, ast.Expr) + "\n"
+	name := "decl " + ast.Name
+	name += p.typeParams(ast.TypeParams)
+	for _, params := range ast.Params {
+		names := make([]string, len(params))
+		for i, param := range params {
+			names[i] = param.Accept(p)
+		}
+		name += " (" + strings.Join(names, ", ") + ")"
+	}
+	if ast.ReturnType != nil {
+		name += " => " + ast.ReturnType.Accept(p)
+	}
+	if ast.Expr == nil {
+		return p.parenthesize(name) + "\n"
+	}
+	return p.parenthesize(name, ast.Expr) + "\n"
+}
+
+func (p AstPrinter) visitTraitDecl(ast *TraitDecl) string {
+	name := "trait " + ast.Name.Text + p.typeParams(ast.TypeParams)
+	return p.parenthesize(name+"\n", methods(ast.Methods)...) + "\n"
+}
+
+func (p AstPrinter) visitImplDecl(ast *ImplDecl) string {
+	name := "impl" + p.typeParams(ast.TypeParams) + " " + ast.Trait.Accept(p)
+	return p.parenthesize(name+"\n", methods(ast.Methods)...) + "\n"
+}
+
+func (p AstPrinter) typeParams(typeParams []*TypeParamAST) string {
+	if len(typeParams) == 0 {
+		return ""
+	}
+	names := make([]string, len(typeParams))
+	for i, typeParam := range typeParams {
+		names[i] = typeParam.Accept(p)
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+func methods(methods []*DeclAST) []AST {
+	asts := make([]AST, len(methods))
+	for i := range methods {
+		asts[i] = methods[i]
+	}
+	return asts
+}
+
+func (p AstPrinter) visitStructDecl(ast *StructDecl) string {
+	name := "struct " + ast.Name.Text
+	name += p.typeParams(ast.TypeParams)
+	asts := make([]AST, len(ast.Fields))
+	for i := range ast.Fields {
+		asts[i] = ast.Fields[i]
+	}
+	return p.parenthesize(name, asts...) + "\n"
+}
+
+func (p AstPrinter) visitFieldAST(ast *FieldAST) string {
+	return ast.Name.Text + ": " + ast.Type.Accept(p)
+}
+
+func (p AstPrinter) visitTypeDecl(ast *TypeDecl) string {
+	name := "type " + ast.Name.Text
+	name += p.typeParams(ast.TypeParams)
+	asts := make([]AST, len(ast.Constructors))
+	for i := range ast.Constructors {
+		asts[i] = ast.Constructors[i]
+	}
+	return p.parenthesize(name, asts...) + "\n"
+}
+
+func (p AstPrinter) visitConstructorAST(ast *ConstructorAST) string {
+	if len(ast.Fields) == 0 {
+		return ast.Name.Text
+	}
+	fields := make([]AST, len(ast.Fields))
+	for i := range ast.Fields {
+		fields[i] = ast.Fields[i]
+	}
+	return p.parenthesize(ast.Name.Text, fields...)
+}
+
+func (p AstPrinter) visitTypeParamAST(ast *TypeParamAST) string {
+	if len(ast.Constraints) == 0 {
+		return ast.Name.Text
+	}
+	constraints := make([]string, len(ast.Constraints))
+	for i, constraint := range ast.Constraints {
+		constraints[i] = constraint.Accept(p)
+	}
+	return ast.Name.Text + ": " + strings.Join(constraints, " + ")
+}
+
+func (p AstPrinter) visitParamAST(ast *ParamAST) string {
+	if ast.Type != nil {
+		return ast.Name.Text + ": " + ast.Type.Accept(p)
+	}
+	return ast.Name.Text
+}
+
+func (p AstPrinter) visitBadDecl(ast *BadDecl) string {
+	return fmt.Sprintf("(bad decl %s)\n", ast.From.Pos)
+}
+
+func (p AstPrinter) visitFixityDecl(ast *FixityDecl) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "(%s %d", ast.Token.Text, ast.Precedence)
+	for _, op := range ast.Operators {
+		sb.WriteByte(' ')
+		sb.WriteString(op.Text)
+	}
+	sb.WriteString(")\n")
+	return sb.String()
+}
+
+func (p AstPrinter) visitBadExpr(expr *BadExpr) string {
+	return fmt.Sprintf("(bad expr %s)", expr.From.Pos)
 }
 
 func (p AstPrinter) visitBinaryExpr(expr *BinaryExpr) string {
 	return p.parenthesize(expr.Op.Text, expr.Left, expr.Right)
 }
 
+func (p AstPrinter) visitApplyExpr(expr *ApplyExpr) string {
+	return p.parenthesize("apply", expr.Fn, expr.Arg)
+}
+
 func (p AstPrinter) visitUnaryExpr(expr *UnaryExpr) string {
 	return p.parenthesize(expr.Op.Text, expr.Expr)
 }
@@ -52,6 +194,76 @@ func (p AstPrinter) visitGroupingExpr(expr *GroupingExpr) string {
 	return p.parenthesize("group", expr.Expr)
 }
 
+func (p AstPrinter) visitLambdaExpr(expr *LambdaExpr) string {
+	params := make([]string, len(expr.Params))
+	for i, param := range expr.Params {
+		params[i] = param.Accept(p)
+	}
+	return p.parenthesize("lambda ("+strings.Join(params, ", ")+")", expr.Body)
+}
+
+func (p AstPrinter) visitIfExpr(expr *IfExpr) string {
+	if expr.Else == nil {
+		return p.parenthesize("if", expr.Cond, expr.Then)
+	}
+	return p.parenthesize("if", expr.Cond, expr.Then, expr.Else)
+}
+
+func (p AstPrinter) visitMatchExpr(expr *MatchExpr) string {
+	asts := []AST{expr.Expr}
+	for _, c := range expr.Cases {
+		asts = append(asts, c)
+	}
+	return p.parenthesize("match", asts...)
+}
+
+func (p AstPrinter) visitCaseAST(ast *CaseAST) string {
+	name := "case " + ast.Pattern.Accept(p)
+	if ast.Guard != nil {
+		name += " " + p.parenthesize("if", ast.Guard)
+	}
+	return p.parenthesize(name, ast.Body)
+}
+
+func (p AstPrinter) visitTupleExpr(expr *TupleExpr) string {
+	return p.parenthesize("tuple", exprs(expr.Elems)...)
+}
+
+func (p AstPrinter) visitListExpr(expr *ListExpr) string {
+	return p.parenthesize("list", exprs(expr.Elems)...)
+}
+
+func (p AstPrinter) visitMapExpr(expr *MapExpr) string {
+	asts := make([]AST, len(expr.Entries))
+	for i := range expr.Entries {
+		asts[i] = expr.Entries[i]
+	}
+	return p.parenthesize("map", asts...)
+}
+
+func (p AstPrinter) visitEntryAST(ast *EntryAST) string {
+	return p.parenthesize("entry", ast.Key, ast.Value)
+}
+
+func exprs(exprs []Expr) []AST {
+	asts := make([]AST, len(exprs))
+	for i := range exprs {
+		asts[i] = exprs[i]
+	}
+	return asts
+}
+
+func (p AstPrinter) visitBlockExpr(expr *BlockExpr) string {
+	asts := make([]AST, len(expr.Stmts), len(expr.Stmts)+1)
+	for i := range expr.Stmts {
+		asts[i] = expr.Stmts[i]
+	}
+	if expr.Value != nil {
+		asts = append(asts, expr.Value)
+	}
+	return p.parenthesize("block", asts...)
+}
+
 func (p AstPrinter) visitBooleanExpr(expr *BooleanExpr) string {
 	return expr.Token.Text
 }
@@ -68,14 +280,66 @@ func (p AstPrinter) visitFloatExpr(expr *FloatExpr) string {
 	return fmt.Sprintf("%f", expr.Value)
 }
 
+func (p AstPrinter) visitCharExpr(expr *CharExpr) string {
+	return fmt.Sprintf("%q", expr.Value)
+}
+
 func (p AstPrinter) visitStringExpr(expr *StringExpr) string {
 	return fmt.Sprintf("\"%s\"", expr.Value)
 }
 
+func (p AstPrinter) visitInterpolatedStringExpr(expr *InterpolatedStringExpr) string {
+	return p.parenthesize("interpolate", exprs(expr.Parts)...)
+}
+
+func (p AstPrinter) visitSymbolExpr(expr *SymbolExpr) string {
+	return "'" + expr.Name
+}
+
 func (p AstPrinter) visitIdentExpr(expr *IdentExpr) string {
 	return expr.Name
 }
 
+func (p AstPrinter) visitFieldExpr(expr *FieldExpr) string {
+	return expr.Expr.Accept(p) + "." + expr.Name.Text
+}
+
+func (p AstPrinter) visitStructExpr(expr *StructExpr) string {
+	return p.parenthesize("struct "+expr.Type.Accept(p), fieldInits(expr.Fields)...)
+}
+
+func (p AstPrinter) visitUpdateExpr(expr *UpdateExpr) string {
+	return p.parenthesize("update", append([]AST{expr.Expr}, fieldInits(expr.Fields)...)...)
+}
+
+func (p AstPrinter) visitFieldInitAST(ast *FieldInitAST) string {
+	return p.parenthesize(ast.Name.Text, ast.Expr)
+}
+
+func fieldInits(fields []*FieldInitAST) []AST {
+	asts := make([]AST, len(fields))
+	for i := range fields {
+		asts[i] = fields[i]
+	}
+	return asts
+}
+
+func (p AstPrinter) visitConstructorExpr(expr *ConstructorExpr) string {
+	return expr.Name
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// A placeholder for an expression that failed to parse
+type BadExpr struct {
+	From text.Token
+	To   text.Token
+}
+
+func (*BadExpr) ast()                            {}
+func (*BadExpr) expr()                           {}
+func (expr *BadExpr) Accept(p AstPrinter) string { return p.visitBadExpr(expr) }
+
 ////////////////////////////////////////////////////////////////////////////////
 
 type BinaryExpr struct {
@@ -90,6 +354,17 @@ func (expr *BinaryExpr) Accept(p AstPrinter) string { return p.visitBinaryExpr(e
 
 ////////////////////////////////////////////////////////////////////////////////
 
+type ApplyExpr struct {
+	Fn  Expr
+	Arg Expr
+}
+
+func (*ApplyExpr) ast()                            {}
+func (*ApplyExpr) expr()                           {}
+func (expr *ApplyExpr) Accept(p AstPrinter) string { return p.visitApplyExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
 type UnaryExpr struct {
 	Op   text.Token
 	Expr Expr
@@ -113,6 +388,114 @@ func (expr *GroupingExpr) Accept(p AstPrinter) string { return p.visitGroupingEx
 
 ////////////////////////////////////////////////////////////////////////////////
 
+type LambdaExpr struct {
+	Params []*ParamAST
+	Arrow  text.Token
+	Body   Expr
+}
+
+func (*LambdaExpr) ast()                            {}
+func (*LambdaExpr) expr()                           {}
+func (expr *LambdaExpr) Accept(p AstPrinter) string { return p.visitLambdaExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type IfExpr struct {
+	Token text.Token
+	Cond  Expr
+	Then  Expr
+	Else  Expr
+}
+
+func (*IfExpr) ast()                            {}
+func (*IfExpr) expr()                           {}
+func (expr *IfExpr) Accept(p AstPrinter) string { return p.visitIfExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type MatchExpr struct {
+	Token text.Token
+	Expr  Expr
+	Cases []*CaseAST
+}
+
+func (*MatchExpr) ast()                            {}
+func (*MatchExpr) expr()                           {}
+func (expr *MatchExpr) Accept(p AstPrinter) string { return p.visitMatchExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type CaseAST struct {
+	Token   text.Token
+	Pattern Pattern
+	Guard   Expr
+	Body    Expr
+}
+
+func (*CaseAST) ast()                         {}
+func (c *CaseAST) Accept(p AstPrinter) string { return p.visitCaseAST(c) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type TupleExpr struct {
+	Lpar  text.Token
+	Elems []Expr
+	Rpar  text.Token
+}
+
+func (*TupleExpr) ast()                            {}
+func (*TupleExpr) expr()                           {}
+func (expr *TupleExpr) Accept(p AstPrinter) string { return p.visitTupleExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type ListExpr struct {
+	Lbrk  text.Token
+	Elems []Expr
+	Rbrk  text.Token
+}
+
+func (*ListExpr) ast()                            {}
+func (*ListExpr) expr()                           {}
+func (expr *ListExpr) Accept(p AstPrinter) string { return p.visitListExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type MapExpr struct {
+	Lbrc    text.Token
+	Entries []*EntryAST
+	Rbrc    text.Token
+}
+
+func (*MapExpr) ast()                            {}
+func (*MapExpr) expr()                           {}
+func (expr *MapExpr) Accept(p AstPrinter) string { return p.visitMapExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type EntryAST struct {
+	Key   Expr
+	Value Expr
+}
+
+func (*EntryAST) ast()                         {}
+func (e *EntryAST) Accept(p AstPrinter) string { return p.visitEntryAST(e) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type BlockExpr struct {
+	Lbrc  text.Token
+	Stmts []Stmt
+	Value Expr
+	Rbrc  text.Token
+}
+
+func (*BlockExpr) ast()                            {}
+func (*BlockExpr) expr()                           {}
+func (expr *BlockExpr) Accept(p AstPrinter) string { return p.visitBlockExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
 type BooleanExpr struct {
 	Token text.Token
 	Value bool
@@ -157,6 +540,17 @@ func (expr *FloatExpr) Accept(p AstPrinter) string { return p.visitFloatExpr(exp
 
 ////////////////////////////////////////////////////////////////////////////////
 
+type CharExpr struct {
+	Token text.Token
+	Value rune
+}
+
+func (*CharExpr) ast()                            {}
+func (*CharExpr) expr()                           {}
+func (expr *CharExpr) Accept(p AstPrinter) string { return p.visitCharExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
 type StringExpr struct {
 	Token text.Token
 	Value string
@@ -168,6 +562,32 @@ func (expr *StringExpr) Accept(p AstPrinter) string { return p.visitStringExpr(e
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// Parts alternate string segments, which may be empty, and the expressions
+// embedded between them, starting and ending with a segment
+type InterpolatedStringExpr struct {
+	Parts []Expr
+}
+
+func (*InterpolatedStringExpr) ast()  {}
+func (*InterpolatedStringExpr) expr() {}
+func (expr *InterpolatedStringExpr) Accept(p AstPrinter) string {
+	return p.visitInterpolatedStringExpr(expr)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Symbols are interned names, written 'name
+type SymbolExpr struct {
+	Token text.Token
+	Name  string
+}
+
+func (*SymbolExpr) ast()                            {}
+func (*SymbolExpr) expr()                           {}
+func (expr *SymbolExpr) Accept(p AstPrinter) string { return p.visitSymbolExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
 type IdentExpr struct {
 	Token text.Token
 	Name  string
@@ -178,3 +598,58 @@ func (*IdentExpr) expr()                           {}
 func (expr *IdentExpr) Accept(p AstPrinter) string { return p.visitIdentExpr(expr) }
 
 ////////////////////////////////////////////////////////////////////////////////
+
+type ConstructorExpr struct {
+	Token text.Token
+	Name  string
+}
+
+func (*ConstructorExpr) ast()                            {}
+func (*ConstructorExpr) expr()                           {}
+func (expr *ConstructorExpr) Accept(p AstPrinter) string { return p.visitConstructorExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type FieldExpr struct {
+	Expr Expr
+	Dot  text.Token
+	Name text.Token
+}
+
+func (*FieldExpr) ast()                            {}
+func (*FieldExpr) expr()                           {}
+func (expr *FieldExpr) Accept(p AstPrinter) string { return p.visitFieldExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type StructExpr struct {
+	Type   Expr
+	Fields []*FieldInitAST
+}
+
+func (*StructExpr) ast()                            {}
+func (*StructExpr) expr()                           {}
+func (expr *StructExpr) Accept(p AstPrinter) string { return p.visitStructExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type UpdateExpr struct {
+	Expr   Expr
+	Fields []*FieldInitAST
+}
+
+func (*UpdateExpr) ast()                            {}
+func (*UpdateExpr) expr()                           {}
+func (expr *UpdateExpr) Accept(p AstPrinter) string { return p.visitUpdateExpr(expr) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type FieldInitAST struct {
+	Name text.Token
+	Expr Expr
+}
+
+func (*FieldInitAST) ast()                         {}
+func (f *FieldInitAST) Accept(p AstPrinter) string { return p.visitFieldInitAST(f) }
+
+////////////////////////////////////////////////////////////////////////////////
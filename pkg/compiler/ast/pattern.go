@@ -0,0 +1,133 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/Spriithy/rosa/pkg/compiler/text"
+)
+
+type Pattern interface {
+	pattern()
+	AST
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (p AstPrinter) visitWildcardPattern(pattern *WildcardPattern) string {
+	return "_"
+}
+
+func (p AstPrinter) visitVarPattern(pattern *VarPattern) string {
+	return pattern.Name.Text
+}
+
+func (p AstPrinter) visitLiteralPattern(pattern *LiteralPattern) string {
+	var lit string
+	switch {
+	case text.String(pattern.Token):
+		lit = fmt.Sprintf("%q", pattern.Token.Text)
+	case text.Char(pattern.Token):
+		lit = "'" + pattern.Token.Text + "'"
+	case text.Symbol(pattern.Token):
+		lit = "'" + pattern.Token.Text
+	default:
+		lit = pattern.Token.Text
+	}
+	if pattern.Negative {
+		return "-" + lit
+	}
+	return lit
+}
+
+func (p AstPrinter) visitConstructorPattern(pattern *ConstructorPattern) string {
+	if len(pattern.Args) == 0 {
+		return pattern.Name.Text
+	}
+	args := make([]AST, len(pattern.Args))
+	for i := range pattern.Args {
+		args[i] = pattern.Args[i]
+	}
+	return p.parenthesize(pattern.Name.Text, args...)
+}
+
+func (p AstPrinter) visitTuplePattern(pattern *TuplePattern) string {
+	elems := make([]AST, len(pattern.Elems))
+	for i := range pattern.Elems {
+		elems[i] = pattern.Elems[i]
+	}
+	return p.parenthesize("tuple", elems...)
+}
+
+func (p AstPrinter) visitListPattern(pattern *ListPattern) string {
+	elems := make([]AST, len(pattern.Elems))
+	for i := range pattern.Elems {
+		elems[i] = pattern.Elems[i]
+	}
+	return p.parenthesize("list", elems...)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type WildcardPattern struct {
+	Token text.Token
+}
+
+func (*WildcardPattern) ast()                               {}
+func (*WildcardPattern) pattern()                           {}
+func (pattern *WildcardPattern) Accept(p AstPrinter) string { return p.visitWildcardPattern(pattern) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type VarPattern struct {
+	Name text.Token
+}
+
+func (*VarPattern) ast()                               {}
+func (*VarPattern) pattern()                           {}
+func (pattern *VarPattern) Accept(p AstPrinter) string { return p.visitVarPattern(pattern) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type LiteralPattern struct {
+	Token    text.Token
+	Negative bool
+}
+
+func (*LiteralPattern) ast()                               {}
+func (*LiteralPattern) pattern()                           {}
+func (pattern *LiteralPattern) Accept(p AstPrinter) string { return p.visitLiteralPattern(pattern) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type ConstructorPattern struct {
+	Name text.Token
+	Args []Pattern
+}
+
+func (*ConstructorPattern) ast()     {}
+func (*ConstructorPattern) pattern() {}
+func (pattern *ConstructorPattern) Accept(p AstPrinter) string {
+	return p.visitConstructorPattern(pattern)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type TuplePattern struct {
+	Lpar  text.Token
+	Elems []Pattern
+}
+
+func (*TuplePattern) ast()                               {}
+func (*TuplePattern) pattern()                           {}
+func (pattern *TuplePattern) Accept(p AstPrinter) string { return p.visitTuplePattern(pattern) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type ListPattern struct {
+	Lbrk  text.Token
+	Elems []Pattern
+}
+
+func (*ListPattern) ast()                               {}
+func (*ListPattern) pattern()                           {}
+func (pattern *ListPattern) Accept(p AstPrinter) string { return p.visitListPattern(pattern) }
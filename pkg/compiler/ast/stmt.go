@@ -0,0 +1,86 @@
+package ast
+
+import "github.com/Spriithy/rosa/pkg/compiler/text"
+
+type Stmt interface {
+	stmt()
+	AST
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (p AstPrinter) visitLetAST(ast *LetAST) string {
+	name := "let "
+	if ast.Mutable {
+		name += "mut "
+	}
+	name += ast.Name.Text
+	if ast.Type != nil {
+		name += ": " + ast.Type.Accept(p)
+	}
+	return p.parenthesize(name, ast.Expr)
+}
+
+func (p AstPrinter) visitAssignStmt(stmt *AssignStmt) string {
+	return p.parenthesize(":= "+stmt.Name.Text, stmt.Expr)
+}
+
+func (p AstPrinter) visitReturnStmt(stmt *ReturnStmt) string {
+	if stmt.Expr == nil {
+		return p.parenthesize("return")
+	}
+	return p.parenthesize("return", stmt.Expr)
+}
+
+func (p AstPrinter) visitExprStmt(stmt *ExprStmt) string {
+	return stmt.Expr.Accept(p)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// A let binding, either at the top level of a module or inside a block
+type LetAST struct {
+	Token   text.Token
+	Mutable bool
+	Name    text.Token
+	Type    Type
+	Expr    Expr
+}
+
+func (*LetAST) ast()                         {}
+func (*LetAST) decl()                        {}
+func (*LetAST) stmt()                        {}
+func (l *LetAST) Accept(p AstPrinter) string { return p.visitLetAST(l) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type AssignStmt struct {
+	Name   text.Token
+	Walrus text.Token
+	Expr   Expr
+}
+
+func (*AssignStmt) ast()                            {}
+func (*AssignStmt) stmt()                           {}
+func (stmt *AssignStmt) Accept(p AstPrinter) string { return p.visitAssignStmt(stmt) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type ReturnStmt struct {
+	Token text.Token
+	Expr  Expr
+}
+
+func (*ReturnStmt) ast()                            {}
+func (*ReturnStmt) stmt()                           {}
+func (stmt *ReturnStmt) Accept(p AstPrinter) string { return p.visitReturnStmt(stmt) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type ExprStmt struct {
+	Expr Expr
+}
+
+func (*ExprStmt) ast()                            {}
+func (*ExprStmt) stmt()                           {}
+func (stmt *ExprStmt) Accept(p AstPrinter) string { return p.visitExprStmt(stmt) }
@@ -0,0 +1,89 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/Spriithy/rosa/pkg/compiler/text"
+)
+
+type Type interface {
+	typ()
+	AST
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (p AstPrinter) visitNamedType(typ *NamedType) string {
+	return typ.Name.Text
+}
+
+func (p AstPrinter) visitAppliedType(typ *AppliedType) string {
+	args := make([]string, len(typ.Args))
+	for i, arg := range typ.Args {
+		args[i] = arg.Accept(p)
+	}
+	return typ.Type.Accept(p) + "[" + strings.Join(args, ", ") + "]"
+}
+
+func (p AstPrinter) visitFuncType(typ *FuncType) string {
+	if len(typ.Params) == 1 {
+		return "(" + typ.Params[0].Accept(p) + " => " + typ.Result.Accept(p) + ")"
+	}
+	params := make([]string, len(typ.Params))
+	for i, param := range typ.Params {
+		params[i] = param.Accept(p)
+	}
+	return "((" + strings.Join(params, ", ") + ") => " + typ.Result.Accept(p) + ")"
+}
+
+func (p AstPrinter) visitTupleType(typ *TupleType) string {
+	elems := make([]string, len(typ.Elems))
+	for i, elem := range typ.Elems {
+		elems[i] = elem.Accept(p)
+	}
+	return "(" + strings.Join(elems, ", ") + ")"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type NamedType struct {
+	Name text.Token
+}
+
+func (*NamedType) ast()                           {}
+func (*NamedType) typ()                           {}
+func (typ *NamedType) Accept(p AstPrinter) string { return p.visitNamedType(typ) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type AppliedType struct {
+	Type Type
+	Args []Type
+}
+
+func (*AppliedType) ast()                           {}
+func (*AppliedType) typ()                           {}
+func (typ *AppliedType) Accept(p AstPrinter) string { return p.visitAppliedType(typ) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type FuncType struct {
+	Params []Type
+	Arrow  text.Token
+	Result Type
+}
+
+func (*FuncType) ast()                           {}
+func (*FuncType) typ()                           {}
+func (typ *FuncType) Accept(p AstPrinter) string { return p.visitFuncType(typ) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+type TupleType struct {
+	Lpar  text.Token
+	Elems []Type
+}
+
+func (*TupleType) ast()                           {}
+func (*TupleType) typ()                           {}
+func (typ *TupleType) Accept(p AstPrinter) string { return p.visitTupleType(typ) }
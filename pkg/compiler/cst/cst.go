@@ -0,0 +1,217 @@
+// Package cst holds the concrete syntax tree of a module: unlike the AST, it
+// keeps every token along with the whitespace and comments between them, so the
+// exact source can be rebuilt from it.
+package cst
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/Spriithy/rosa/pkg/compiler/ast"
+	"github.com/Spriithy/rosa/pkg/compiler/text"
+)
+
+// Inner nodes are of the kind of the AST node they stand for, such as
+// "BinaryExpr" or "DeclAST"
+type Kind string
+
+const (
+	TokenKind  Kind = "Token"
+	TriviaKind Kind = "Trivia"
+)
+
+type Node struct {
+	Kind     Kind
+	AST      ast.AST    // inner nodes only
+	Token    text.Token // leaves only, unset for trivia the scanner didn't keep
+	Text     string     // leaves only, as found in the source
+	Parent   *Node
+	Children []*Node
+}
+
+func (n *Node) IsToken() bool {
+	return n.Kind == TokenKind
+}
+
+func (n *Node) IsTrivia() bool {
+	return n.Kind == TriviaKind
+}
+
+func (n *Node) IsLeaf() bool {
+	return n.IsToken() || n.IsTrivia()
+}
+
+// String rebuilds the source text spanned by the node
+func (n *Node) String() string {
+	var b strings.Builder
+	n.Walk(func(n *Node) bool {
+		b.WriteString(n.Text)
+		return true
+	})
+	return b.String()
+}
+
+// Walk visits the node and its descendants in source order, skipping the
+// children of the nodes for which f returns false
+func (n *Node) Walk(f func(*Node) bool) {
+	if !f(n) {
+		return
+	}
+	for _, child := range n.Children {
+		child.Walk(f)
+	}
+}
+
+// Leaves lists the tokens and trivia spanned by the node, in source order
+func (n *Node) Leaves() (leaves []*Node) {
+	n.Walk(func(n *Node) bool {
+		if n.IsLeaf() {
+			leaves = append(leaves, n)
+		}
+		return true
+	})
+	return
+}
+
+// Tokens lists the tokens spanned by the node, leaving out trivia
+func (n *Node) Tokens() (tokens []*Node) {
+	for _, leaf := range n.Leaves() {
+		if leaf.IsToken() {
+			tokens = append(tokens, leaf)
+		}
+	}
+	return
+}
+
+// Index is the position of the node among its siblings, -1 for the root
+func (n *Node) Index() int {
+	if n.Parent != nil {
+		for i, sibling := range n.Parent.Children {
+			if sibling == n {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (n *Node) PrevSibling() *Node {
+	if i := n.Index(); i > 0 {
+		return n.Parent.Children[i-1]
+	}
+	return nil
+}
+
+func (n *Node) NextSibling() *Node {
+	if i := n.Index(); i >= 0 && i+1 < len(n.Parent.Children) {
+		return n.Parent.Children[i+1]
+	}
+	return nil
+}
+
+// Find returns the innermost node standing for the given AST node, or nil
+func (n *Node) Find(node ast.AST) (found *Node) {
+	n.Walk(func(n *Node) bool {
+		if n.AST == node {
+			found = n
+		}
+		return true
+	})
+	return
+}
+
+// At returns the innermost node spanning the given source offset, or nil
+func (n *Node) At(offset int) (found *Node) {
+	start := 0
+	n.Walk(func(n *Node) bool {
+		if n.IsLeaf() {
+			if start <= offset && offset < start+len([]rune(n.Text)) {
+				found = n
+			}
+			start += len([]rune(n.Text))
+		}
+		return true
+	})
+	return
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Builder assembles the tree as the parser reads tokens. Nodes are built bottom
+// up: the parser marks where a construct starts, and wraps everything read
+// since then once it knows what the construct is.
+type Builder struct {
+	source []rune
+	offset int
+	nodes  []*Node
+}
+
+func NewBuilder(source []rune) *Builder {
+	return &Builder{
+		source: source,
+	}
+}
+
+// Token appends a token leaf along with its trivia. When the scanner didn't keep
+// trivia, the source found since the previous token makes a single trivia leaf.
+func (b *Builder) Token(token text.Token) {
+	b.leaves(TriviaKind, token.Leading...)
+	if !text.Eof(token) {
+		b.leaves(TokenKind, token)
+	}
+	b.leaves(TriviaKind, token.Trailing...)
+}
+
+func (b *Builder) leaves(kind Kind, tokens ...text.Token) {
+	for _, token := range tokens {
+		b.trivia(token.Offset)
+		b.nodes = append(b.nodes, &Node{
+			Kind:  kind,
+			Token: token,
+			Text:  string(b.source[token.Offset:token.Spans]),
+		})
+		b.offset = token.Spans
+	}
+}
+
+func (b *Builder) trivia(end int) {
+	if end > b.offset {
+		b.nodes = append(b.nodes, &Node{
+			Kind: TriviaKind,
+			Text: string(b.source[b.offset:end]),
+		})
+		b.offset = end
+	}
+}
+
+func (b *Builder) Mark() int {
+	return len(b.nodes)
+}
+
+// Wrap gathers the nodes appended since mark under a node standing for the
+// given AST node. Nodes already standing for it aren't wrapped twice.
+func (b *Builder) Wrap(mark int, node ast.AST) {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return
+	}
+	if mark == len(b.nodes)-1 && b.nodes[mark].AST == node {
+		return
+	}
+	parent := &Node{
+		Kind:     Kind(reflect.TypeOf(node).Elem().Name()),
+		AST:      node,
+		Children: append([]*Node{}, b.nodes[mark:]...),
+	}
+	for _, child := range parent.Children {
+		child.Parent = parent
+	}
+	b.nodes = append(b.nodes[:mark], parent)
+}
+
+// Finish wraps every node under the module, along with the trailing trivia of
+// the source
+func (b *Builder) Finish(module ast.AST) *Node {
+	b.trivia(len(b.source))
+	b.Wrap(0, module)
+	return b.nodes[0]
+}
@@ -0,0 +1,85 @@
+package cst_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Spriithy/rosa/pkg/compiler"
+	"github.com/Spriithy/rosa/pkg/compiler/ast"
+	"github.com/Spriithy/rosa/pkg/compiler/cst"
+)
+
+func syntaxTree(source string, trivia bool) (ast.AST, *cst.Node) {
+	p := compiler.NewStringParser("test.rosa", source)
+	if trivia {
+		p.Scanner.KeepTrivia()
+	}
+	module, _ := p.Parse()
+	return module, p.SyntaxTree()
+}
+
+func TestRoundTrip(t *testing.T) {
+	sources := []string{
+		"",
+		"module m",
+		"  // leading comment\nmodule m\n\n/// doc\ndef f = 1 // trailing\n\n",
+		"module m\r\ndef f = {\r\n  let x = 1\r\n  x + 1\r\n}\r\n",
+		"module m\n/* block\n comment */ def f(x: Int) = \"é${x}\" + '\\n'\n",
+		"module m\ndef s = \"\"\"\n  raw \"quoted\"\n  \"\"\"\n\t",
+		"module m\ndef\ndef g = 1\nstruct\n)\ndef h = {\n let = 3\n}\n",
+		"module m\ndef f = \"a${x",
+	}
+	for _, source := range sources {
+		for _, trivia := range []bool{false, true} {
+			_, tree := syntaxTree(source, trivia)
+			if rebuilt := tree.String(); rebuilt != source {
+				t.Errorf("trivia %t: rebuilt %q from %q", trivia, rebuilt, source)
+			}
+			tree.Walk(func(n *cst.Node) bool {
+				for _, child := range n.Children {
+					if child.Parent != n {
+						t.Errorf("trivia %t: %s node of %q has a wrong parent", trivia, child.Kind, source)
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	source := "module m\ndef f = a + b // sum\n"
+	module, tree := syntaxTree(source, true)
+	expr := module.(*ast.ModuleAST).Decls[0].(*ast.DeclAST).Expr
+
+	// trivia on the line of a token trails it
+	node := tree.Find(expr)
+	if node == nil || node.Kind != "BinaryExpr" || node.String() != "a + b // sum" {
+		t.Fatalf("found %v for the binary expression", node)
+	}
+	if tokens := node.Tokens(); len(tokens) != 3 || tokens[1].Text != "+" {
+		t.Errorf("binary expression spans tokens %v", tokens)
+	}
+
+	plus := tree.At(strings.Index(source, "+"))
+	if plus == nil || !plus.IsToken() || plus.Text != "+" {
+		t.Fatalf("found %v at the offset of '+'", plus)
+	}
+	for n := plus; n != tree; n = n.Parent {
+		if n.Parent == nil {
+			t.Fatalf("%s node isn't linked to the root", n.Kind)
+		}
+	}
+	if prev := plus.PrevSibling(); prev == nil || prev.Kind != "IdentExpr" || prev.String() != "a " {
+		t.Errorf("'+' comes after %v", prev)
+	}
+	if next := plus.NextSibling(); next == nil || !next.IsTrivia() || next.Text != " " {
+		t.Errorf("'+' comes before %v", next)
+	}
+	if tree.Index() != -1 || tree.PrevSibling() != nil || tree.NextSibling() != nil {
+		t.Errorf("the root has siblings")
+	}
+	if comment := tree.At(strings.Index(source, "//")); comment == nil || !comment.IsTrivia() || comment.Text != "// sum" {
+		t.Errorf("found %v at the offset of the comment", comment)
+	}
+}
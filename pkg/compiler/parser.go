@@ -3,9 +3,14 @@ package compiler
 import (
 	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/Spriithy/rosa/pkg/compiler/ast"
+	"github.com/Spriithy/rosa/pkg/compiler/cst"
 	"github.com/Spriithy/rosa/pkg/compiler/text"
 )
 
@@ -15,177 +20,1740 @@ type Parser struct {
 	tokens  *[]text.Token
 	current int
 	Logs    []Log
+
+	// Fixities declared in this module, and those imported from other ones
+	fixities map[string]fixity
+	imported map[string]fixity
+	// Fixity declarations are parsed ahead of the module, they are found here
+	// by the index of their keyword when met again
+	fixityDecls map[int]parsedFixity
+	// Modules import declarations may bring operator fixities from
+	modules map[string]*ast.ModuleAST
+
+	scopes        []scope
+	functionDepth int
+	// Line breaks end statements in blocks, but not within parentheses,
+	// brackets or maps nested in them
+	lineBreaks bool
+	// Braces after the condition of if and match open their body rather than
+	// a record, unless nested in parentheses, brackets or braces
+	noRecords bool
+
+	syntax *cst.Builder
+	tree   *cst.Node
+}
+
+// NewParser reads the whole source from r, name being the file name reported in
+// positions and logs
+func NewParser(name string, r io.Reader) (*Parser, error) {
+	scanner, err := NewScanner(name, r)
+	if err != nil {
+		return nil, err
+	}
+	return newParser(scanner), nil
+}
+
+func NewStringParser(name, source string) *Parser {
+	return newParser(NewStringScanner(name, source))
+}
+
+func OpenParser(path string) (*Parser, error) {
+	scanner, err := OpenScanner(path)
+	if err != nil {
+		return nil, err
+	}
+	return newParser(scanner), nil
+}
+
+func newParser(scanner *Scanner) *Parser {
+	return &Parser{
+		path:        scanner.path,
+		Scanner:     scanner,
+		tokens:      &scanner.tokens,
+		fixities:    map[string]fixity{},
+		imported:    map[string]fixity{},
+		fixityDecls: map[int]parsedFixity{},
+		modules:     map[string]*ast.ModuleAST{},
+		syntax:      cst.NewBuilder(scanner.source),
+	}
+}
+
+// Import makes an already parsed module available to the import declarations
+// of this one. The parser doesn't load modules itself: whoever does must import
+// every module this one depends on before calling Parse, so that importing it
+// brings the fixities of the operators it declares, or of the selected ones.
+func (p *Parser) Import(module *ast.ModuleAST) {
+	p.modules[module.Name] = module
+}
+
+func (p *Parser) error(token text.Token, err error) {
+	p.Logs = append(p.Logs, Log{
+		Path:    p.path,
+		Level:   LogError,
+		Message: err.Error(),
+		Pos:     token.Pos,
+	})
+}
+
+func (p *Parser) errorf(token text.Token, message string, args ...interface{}) {
+	p.Logs = append(p.Logs, Log{
+		Path:    p.path,
+		Level:   LogError,
+		Message: fmt.Sprintf(message, args...),
+		Pos:     token.Pos,
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (p *Parser) eof() bool {
+	return p.current >= len(*p.tokens) || text.Eof((*p.tokens)[p.current])
+}
+
+func (p *Parser) peek(n int) text.Token {
+	switch i := p.current + n; {
+	case len(*p.tokens) == 0 || i < 0:
+		return text.Token{Type: text.EOF}
+	case i >= len(*p.tokens):
+		return (*p.tokens)[len(*p.tokens)-1]
+	}
+	return (*p.tokens)[p.current+n]
+}
+
+func (p *Parser) previous() text.Token {
+	return p.peek(-1)
+}
+
+// The next token, which is the end of file once every other one was read
+func (p *Parser) lookahead() text.Token {
+	return p.peek(0)
+}
+
+// Describes a token in messages, the end of file having no text
+func describe(token text.Token) string {
+	if text.Eof(token) {
+		return "end of file"
+	}
+	return fmt.Sprintf("'%s'", token.Text)
+}
+
+func (p *Parser) advance() text.Token {
+	if !p.eof() {
+		p.syntax.Token(p.peek(0))
+		p.current++
+	}
+	return p.previous()
+}
+
+func (p *Parser) match(fs ...func(text.Token) bool) bool {
+	if p.eof() {
+		return false
+	}
+	for _, f := range fs {
+		if f(p.lookahead()) {
+			p.advance()
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) expect(fs ...func(text.Token) bool) func(string) (text.Token, error) {
+	return func(message string) (token text.Token, err error) {
+		for _, f := range fs {
+			if f(p.lookahead()) {
+				p.advance()
+				token = p.previous()
+				return
+			}
+		}
+		token = p.lookahead()
+		err = errors.New(message)
+		return
+	}
+}
+
+// Marks the start of a construct in the syntax tree
+func (p *Parser) mark() int {
+	return p.syntax.Mark()
+}
+
+// Wraps the tokens read since mark into a syntax node standing for node
+func (p *Parser) wrap(mark int, node ast.AST) {
+	p.syntax.Wrap(mark, node)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Skips tokens up to the next declaration keyword starting a line, leaving out
+// the ones nested in braces opened since the declaration at start. At least one
+// token is skipped when none was read since then, so recovery always progresses.
+func (p *Parser) sync(start int) {
+	depth := 0
+	for _, token := range (*p.tokens)[start:p.current] {
+		switch {
+		case text.Lbrc(token):
+			depth++
+		case text.Rbrc(token):
+			depth--
+		}
+	}
+	for !p.eof() && (p.current == start || depth > 0 || !p.isDeclStart()) {
+		switch token := p.advance(); {
+		case text.Lbrc(token):
+			depth++
+		case text.Rbrc(token):
+			depth--
+		}
+	}
+}
+
+func (p *Parser) isDeclStart() bool {
+	token := p.lookahead()
+	if p.current > 0 && token.Line == p.previous().Line {
+		return false
+	}
+	return text.Decl(token) || text.Module(token) || text.Import(token)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Parse scans and parses the whole source, returning the module along with
+// every log of both the scanner and the parser, ordered by position
+func (p *Parser) Parse() (ast.AST, []Log) {
+	eof := p.Scanner.Scan()
+	for !text.Eof(eof) {
+		eof = p.Scanner.Scan()
+	}
+	// errors at the end of the source are reported on the end of file token
+	if n := len(*p.tokens); n == 0 || !text.Eof((*p.tokens)[n-1]) {
+		*p.tokens = append(*p.tokens, eof)
+	}
+	p.collectFixities()
+	module := p.compilationUnit()
+	p.syntax.Token(eof) // trivia ending the source
+	p.tree = p.syntax.Finish(module)
+	logs := append(append([]Log{}, p.Scanner.Logs...), p.Logs...)
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].Pos.Line != logs[j].Pos.Line {
+			return logs[i].Pos.Line < logs[j].Pos.Line
+		}
+		return logs[i].Pos.Column < logs[j].Pos.Column
+	})
+	return module, logs
+}
+
+// SyntaxTree is the lossless syntax tree of the module, once parsed
+func (p *Parser) SyntaxTree() *cst.Node {
+	return p.tree
+}
+
+// Fixity declarations hold for the whole module, so they are parsed before any
+// expression, against a syntax tree of their own as their tokens are read again
+// along with the rest of the module
+func (p *Parser) collectFixities() {
+	syntax, declared := p.syntax, map[string]bool{}
+	p.syntax = cst.NewBuilder(p.Scanner.source)
+	defer func() {
+		p.current, p.syntax = 0, syntax
+	}()
+	for p.current = 0; !p.eof(); {
+		if !p.match(text.Fixity) {
+			p.advance()
+			continue
+		}
+		start := p.current - 1
+		decl := p.fixityDecl(declared)
+		p.fixityDecls[start] = parsedFixity{
+			decl: decl,
+			end:  p.current,
+		}
+	}
+}
+
+// Top level bindings are visible from every function body of the module, even
+// those preceding them, so they are declared before any body gets parsed
+func (p *Parser) collectTopLevelNames() {
+	depth := 0
+	for i := p.current; i+1 < len(*p.tokens); i++ {
+		switch token := (*p.tokens)[i]; {
+		case text.Lbrc(token):
+			depth++
+		case text.Rbrc(token):
+			depth--
+		case depth != 0:
+		case text.Def(token) && text.Identifier((*p.tokens)[i+1]):
+			p.declare((*p.tokens)[i+1], false)
+		case text.Let(token):
+			mutable := text.Mut((*p.tokens)[i+1])
+			if mutable {
+				i++
+			}
+			if i+1 < len(*p.tokens) && text.Identifier((*p.tokens)[i+1]) {
+				p.declare((*p.tokens)[i+1], mutable)
+			}
+		}
+	}
+}
+
+func (p *Parser) compilationUnit() (module *ast.ModuleAST) {
+	module = &ast.ModuleAST{
+		Name: "<invalid>",
+	}
+	if p.match(text.Module) {
+		module.Tokens = []text.Token{p.previous()}
+		logs := len(p.Logs)
+		p.modulePath(module)
+		// the name stays invalid unless every segment of the path is
+		if len(p.Logs) == logs {
+			module.Name = strings.Join(module.Path, ".")
+		}
+	} else {
+		p.errorf(p.lookahead(), "expected module declaration")
+	}
+	p.openScope()
+	defer p.closeScope()
+	p.collectTopLevelNames()
+	for !p.eof() {
+		start, logs, mark := p.current, len(p.Logs), p.mark()
+		decl := p.topLevelDecl(module)
+		if decl == nil {
+			p.sync(start)
+			p.badDecl(start, mark, module)
+			continue
+		}
+		p.wrap(mark, decl)
+		if !p.eof() && !p.isDeclStart() {
+			// don't report the leftovers of a declaration which already failed
+			if len(p.Logs) == logs {
+				p.errorf(p.lookahead(), "expected declaration, found %s", describe(p.lookahead()))
+			}
+			// braces the declaration left open still count
+			leftovers, mark := p.current, p.mark()
+			p.sync(start)
+			p.badDecl(leftovers, mark, module)
+		}
+	}
+	return
+}
+
+func (p *Parser) badDecl(start, mark int, module *ast.ModuleAST) {
+	bad := &ast.BadDecl{
+		From: (*p.tokens)[start],
+		To:   p.previous(),
+	}
+	p.wrap(mark, bad)
+	module.Decls = append(module.Decls, bad)
+}
+
+func (p *Parser) topLevelDecl(module *ast.ModuleAST) ast.AST {
+	if p.match(text.Import) {
+		if len(module.Decls) > 0 {
+			p.errorf(p.previous(), "imports must precede declarations")
+		}
+		if decl := p.importDecl(module); decl != nil {
+			return decl
+		}
+		return nil
+	}
+	if decl := p.decl(module); decl != nil {
+		return decl
+	}
+	return nil
+}
+
+// The module path is either dotted, as in module rosa.compiler, or given as a
+// string literal, as in module "rosa.compiler". Both forms must name the same
+// identifier segments so they can be matched against the directory layout.
+func (p *Parser) modulePath(module *ast.ModuleAST) {
+	if p.match(text.String) {
+		token := p.previous()
+		module.Tokens = append(module.Tokens, token)
+		for _, segment := range strings.Split(token.Text, ".") {
+			if !isIdentifier(segment) {
+				p.errorf(token, "invalid module path %q, segment %q is not an identifier", token.Text, segment)
+			}
+			module.Path = append(module.Path, segment)
+		}
+		return
+	}
+	for ok := true; ok; ok = p.match(text.Dot) {
+		name, err := p.expect(text.Identifier)("expected module name after 'module' token")
+		if err != nil {
+			p.error(name, err)
+			return
+		}
+		module.Tokens = append(module.Tokens, name)
+		module.Path = append(module.Path, name.Text)
+	}
+}
+
+func isIdentifier(str string) bool {
+	for i, r := range str {
+		if i == 0 && !text.IdentStart(r) || i > 0 && !text.IdentRest(r) && r != '_' {
+			return false
+		}
+	}
+	return str != "" && text.TypeOfToken(str) == text.IdentifierType
+}
+
+// import a.b.c, import a.b as c or import a.b.{x, y}
+func (p *Parser) importDecl(module *ast.ModuleAST) (decl *ast.ImportDecl) {
+	decl = &ast.ImportDecl{
+		Token: p.previous(),
+	}
+	for ok := true; ok; ok = p.match(text.Dot) {
+		if p.match(text.Lbrc) {
+			if decl.Selectors = p.importSelectors(); decl.Selectors == nil {
+				return nil
+			}
+			break
+		}
+		name, err := p.expect(text.Identifier)("expected module name in import")
+		if err != nil {
+			p.error(name, err)
+			return nil
+		}
+		decl.Path = append(decl.Path, name)
+	}
+	if p.match(text.As) {
+		if decl.Selectors != nil {
+			p.errorf(p.previous(), "cannot alias a selective import")
+		}
+		alias, err := p.expect(text.Identifier)("expected alias name after 'as'")
+		if err != nil {
+			p.error(alias, err)
+			return nil
+		}
+		decl.Alias = alias
+	}
+	module.Imports = append(module.Imports, decl)
+	p.importFixities(decl)
+	return
+}
+
+// Brings the fixities declared by an imported module, as long as it was given to
+// Import, keeping only those of the selected operators if any
+func (p *Parser) importFixities(decl *ast.ImportDecl) {
+	var path []string
+	for _, name := range decl.Path {
+		path = append(path, name.Text)
+	}
+	module, ok := p.modules[strings.Join(path, ".")]
+	if !ok {
+		return
+	}
+	selected := map[string]bool{}
+	for _, selector := range decl.Selectors {
+		selected[selector.Text] = true
+	}
+	for _, d := range module.Decls {
+		if fixityDecl, ok := d.(*ast.FixityDecl); ok {
+			for _, op := range fixityDecl.Operators {
+				if decl.Selectors == nil || selected[op.Text] {
+					p.imported[op.Text] = fixityOfDecl(fixityDecl)
+				}
+			}
+		}
+	}
+}
+
+func (p *Parser) importSelectors() (selectors []text.Token) {
+	for ok := true; ok; ok = p.match(text.Comma) {
+		name, err := p.expect(text.Identifier, text.Operator)("expected imported name")
+		if err != nil {
+			p.error(name, err)
+			return nil
+		}
+		selectors = append(selectors, name)
+	}
+	if rbrc, err := p.expect(text.Rbrc)("expected '}' after imported names"); err != nil {
+		p.error(rbrc, err)
+		return nil
+	}
+	return
+}
+
+func (p *Parser) decl(module *ast.ModuleAST) ast.Decl {
+	switch {
+	case p.match(text.Def):
+		if decl := p.def(module); decl != nil {
+			p.declare(decl.Tokens[0], false)
+			return decl
+		}
+	case p.match(text.Let):
+		if decl := p.let(); decl != nil {
+			module.Decls = append(module.Decls, decl)
+			return decl
+		}
+	case p.match(text.Struct):
+		if decl := p.structDecl(module); decl != nil {
+			return decl
+		}
+	case p.match(text.Trait):
+		if decl := p.traitDecl(module); decl != nil {
+			return decl
+		}
+	case p.match(text.Impl):
+		if decl := p.implDecl(module); decl != nil {
+			return decl
+		}
+	case p.match(text.Type):
+		if decl := p.typeDecl(module); decl != nil {
+			return decl
+		}
+	case p.match(text.Fixity):
+		if decl := p.fixity(module); decl != nil {
+			return decl
+		}
+	default:
+		p.errorf(p.lookahead(), "expected declaration, found %s", describe(p.lookahead()))
+	}
+	return nil
+}
+
+func (p *Parser) def(module *ast.ModuleAST) (decl *ast.DeclAST) {
+	if decl = p.defDecl(false); decl == nil {
+		return nil
+	}
+	module.Decls = append(module.Decls, decl)
+	return
+}
+
+// Abstract definitions, such as trait methods, may omit their body
+func (p *Parser) defDecl(abstract bool) (decl *ast.DeclAST) {
+	decl = &ast.DeclAST{}
+	defName, err := p.expect(text.Identifier, text.Operator)("expected identifier")
+	if err != nil {
+		p.error(defName, err)
+		return nil
+	}
+	decl.Name = defName.Text
+	decl.Tokens = []text.Token{defName}
+	if p.match(text.Lbrk) {
+		if decl.TypeParams = p.typeParams(); decl.TypeParams == nil {
+			return nil
+		}
+	}
+	for !p.eof() && (text.Identifier(p.lookahead()) || text.Lpar(p.lookahead())) {
+		params := p.params()
+		if params == nil {
+			return nil
+		}
+		decl.Params = append(decl.Params, params)
+	}
+	if p.match(text.Arrow) {
+		if decl.ReturnType = p.typ(); decl.ReturnType == nil {
+			return nil
+		}
+	}
+	if abstract && !p.match(text.Assign) {
+		return
+	}
+	if !abstract {
+		if _, err := p.expect(text.Assign)("expected '=' after definition signature"); err != nil {
+			p.error(p.lookahead(), err)
+			return nil
+		}
+	}
+	p.openFunction(decl.Params...)
+	decl.Expr = p.expr()
+	p.closeFunction()
+	return
+}
+
+// A type class: trait Show[T] { def show(t: T) => String }
+func (p *Parser) traitDecl(module *ast.ModuleAST) (decl *ast.TraitDecl) {
+	decl = &ast.TraitDecl{
+		Token: p.previous(),
+	}
+	name, err := p.expect(text.Identifier)("expected trait name")
+	if err != nil {
+		p.error(name, err)
+		return nil
+	}
+	decl.Name = name
+	if p.match(text.Lbrk) {
+		if decl.TypeParams = p.typeParams(); decl.TypeParams == nil {
+			return nil
+		}
+	}
+	if decl.Methods = p.methods(true); decl.Methods == nil {
+		return nil
+	}
+	module.Decls = append(module.Decls, decl)
+	return
+}
+
+// An instance of a trait for some types: impl[T: Show] Show[Maybe[T]] { ... }
+func (p *Parser) implDecl(module *ast.ModuleAST) (decl *ast.ImplDecl) {
+	decl = &ast.ImplDecl{
+		Token: p.previous(),
+	}
+	if p.match(text.Lbrk) {
+		if decl.TypeParams = p.typeParams(); decl.TypeParams == nil {
+			return nil
+		}
+	}
+	if decl.Trait = p.appliedType(); decl.Trait == nil {
+		return nil
+	}
+	if decl.Methods = p.methods(false); decl.Methods == nil {
+		return nil
+	}
+	module.Decls = append(module.Decls, decl)
+	return
+}
+
+func (p *Parser) methods(abstract bool) (methods []*ast.DeclAST) {
+	if lbrc, err := p.expect(text.Lbrc)("expected '{' before method definitions"); err != nil {
+		p.error(lbrc, err)
+		return nil
+	}
+	methods = []*ast.DeclAST{}
+	for mark := p.mark(); p.match(text.Def); mark = p.mark() {
+		method := p.defDecl(abstract)
+		if method == nil {
+			return nil
+		}
+		p.wrap(mark, method)
+		methods = append(methods, method)
+		p.match(text.Semicolon)
+	}
+	if rbrc, err := p.expect(text.Rbrc)("expected 'def' or '}' in method definitions"); err != nil {
+		p.error(rbrc, err)
+		return nil
+	}
+	return
+}
+
+// A record type with named fields: struct Point { x: Float, y: Float }
+func (p *Parser) structDecl(module *ast.ModuleAST) (decl *ast.StructDecl) {
+	decl = &ast.StructDecl{
+		Token: p.previous(),
+	}
+	name, err := p.expect(text.Identifier)("expected struct name")
+	if err != nil {
+		p.error(name, err)
+		return nil
+	}
+	if !isConstructorName(name) {
+		p.errorf(name, "struct name '%s' must start with an uppercase letter", name.Text)
+	}
+	decl.Name = name
+	if p.match(text.Lbrk) {
+		if decl.TypeParams = p.typeParams(); decl.TypeParams == nil {
+			return nil
+		}
+	}
+	if lbrc, err := p.expect(text.Lbrc)("expected '{' after struct name"); err != nil {
+		p.error(lbrc, err)
+		return nil
+	}
+	for !p.eof() && !text.Rbrc(p.lookahead()) {
+		mark := p.mark()
+		field := &ast.FieldAST{}
+		if field.Name, err = p.expect(text.Identifier)("expected field name"); err != nil {
+			p.error(field.Name, err)
+			return nil
+		}
+		if colon, err := p.expect(text.Colon)("expected ':' after field name"); err != nil {
+			p.error(colon, err)
+			return nil
+		}
+		if field.Type = p.typ(); field.Type == nil {
+			return nil
+		}
+		p.wrap(mark, field)
+		decl.Fields = append(decl.Fields, field)
+		if !p.match(text.Comma) && !text.Rbrc(p.lookahead()) && p.lookahead().Line == p.previous().Line {
+			p.errorf(p.lookahead(), "expected ',' or line break after field, found %s", describe(p.lookahead()))
+			return nil
+		}
+	}
+	if rbrc, err := p.expect(text.Rbrc)("expected '}' to close struct declaration"); err != nil {
+		p.error(rbrc, err)
+		return nil
+	}
+	module.Decls = append(module.Decls, decl)
+	return
+}
+
+// An algebraic data type, as a sum of constructors each taking a product of
+// types: type Maybe[T] = Just T | None
+func (p *Parser) typeDecl(module *ast.ModuleAST) (decl *ast.TypeDecl) {
+	decl = &ast.TypeDecl{
+		Token: p.previous(),
+	}
+	name, err := p.expect(text.Identifier)("expected type name")
+	if err != nil {
+		p.error(name, err)
+		return nil
+	}
+	decl.Name = name
+	if p.match(text.Lbrk) {
+		if decl.TypeParams = p.typeParams(); decl.TypeParams == nil {
+			return nil
+		}
+	}
+	if assign, err := p.expect(text.Assign)("expected '=' after type name"); err != nil {
+		p.error(assign, err)
+		return nil
+	}
+	p.match(text.Or) // optional leading '|'
+	for ok := true; ok; ok = p.match(text.Or) {
+		mark := p.mark()
+		constructor := p.constructor()
+		if constructor == nil {
+			return nil
+		}
+		p.wrap(mark, constructor)
+		decl.Constructors = append(decl.Constructors, constructor)
+	}
+	module.Decls = append(module.Decls, decl)
+	return
+}
+
+func (p *Parser) constructor() (constructor *ast.ConstructorAST) {
+	name, err := p.expect(text.Identifier)("expected constructor name")
+	if err != nil {
+		p.error(name, err)
+		return nil
+	}
+	if !isConstructorName(name) {
+		p.errorf(name, "constructor name '%s' must start with an uppercase letter", name.Text)
+	}
+	constructor = &ast.ConstructorAST{
+		Name: name,
+	}
+	for !p.eof() && (text.Identifier(p.lookahead()) || text.Lpar(p.lookahead())) {
+		field := p.appliedType()
+		if field == nil {
+			return nil
+		}
+		constructor.Fields = append(constructor.Fields, field)
+	}
+	return
+}
+
+// Constructors are told apart from other identifiers by their leading
+// uppercase letter, both in expressions and patterns
+func isConstructorName(token text.Token) bool {
+	for _, r := range token.Text {
+		return text.Upper(r)
+	}
+	return false
+}
+
+// Type parameters, each optionally constrained by traits: [T: Show + Eq, U]
+func (p *Parser) typeParams() (typeParams []*ast.TypeParamAST) {
+	for ok := true; ok; ok = p.match(text.Comma) {
+		mark := p.mark()
+		name, err := p.expect(text.Identifier)("expected type parameter name")
+		if err != nil {
+			p.error(name, err)
+			return nil
+		}
+		typeParam := &ast.TypeParamAST{Name: name}
+		if p.match(text.Colon) {
+			for ok := true; ok; ok = p.match(text.Plus) {
+				constraint := p.appliedType()
+				if constraint == nil {
+					return nil
+				}
+				typeParam.Constraints = append(typeParam.Constraints, constraint)
+			}
+		}
+		p.wrap(mark, typeParam)
+		typeParams = append(typeParams, typeParam)
+	}
+	if rbrk, err := p.expect(text.Rbrk)("expected ']' after type parameters"); err != nil {
+		p.error(rbrk, err)
+		return nil
+	}
+	return
+}
+
+// A single curried parameter list, either a lone identifier or a
+// parenthesized, comma separated list of optionally typed parameters
+func (p *Parser) params() (params []*ast.ParamAST) {
+	if mark := p.mark(); p.match(text.Identifier) {
+		param := &ast.ParamAST{Name: p.previous()}
+		p.wrap(mark, param)
+		return []*ast.ParamAST{param}
+	}
+	p.advance() // '('
+	params = []*ast.ParamAST{}
+	if p.match(text.Rpar) {
+		return
+	}
+	for ok := true; ok; ok = p.match(text.Comma) {
+		mark := p.mark()
+		name, err := p.expect(text.Identifier)("expected parameter name")
+		if err != nil {
+			p.error(name, err)
+			return nil
+		}
+		param := &ast.ParamAST{Name: name}
+		if p.match(text.Colon) {
+			if param.Type = p.typ(); param.Type == nil {
+				return nil
+			}
+		}
+		p.wrap(mark, param)
+		params = append(params, param)
+	}
+	if rpar, err := p.expect(text.Rpar)("expected ')' after parameters"); err != nil {
+		p.error(rpar, err)
+		return nil
+	}
+	return
+}
+
+type parsedFixity struct {
+	decl *ast.FixityDecl
+	end  int
+}
+
+// Fixity declarations were parsed by collectFixities already, their tokens are
+// only read again
+func (p *Parser) fixity(module *ast.ModuleAST) *ast.FixityDecl {
+	parsed := p.fixityDecls[p.current-1]
+	for p.current < parsed.end {
+		p.advance()
+	}
+	if parsed.decl != nil {
+		module.Decls = append(module.Decls, parsed.decl)
+	}
+	return parsed.decl
+}
+
+// infixl 6 +++, ---
+func (p *Parser) fixityDecl(declared map[string]bool) (decl *ast.FixityDecl) {
+	decl = &ast.FixityDecl{
+		Token: p.previous(),
+	}
+	precedence, err := p.expect(text.Integer)("expected operator precedence")
+	if err != nil {
+		p.error(precedence, err)
+		return nil
+	}
+	decl.Precedence, _ = strconv.Atoi(precedence.Text)
+	valid := decl.Precedence <= specialPrecedence
+	if !valid {
+		p.errorf(precedence, "operator precedence must be between 0 and %d", specialPrecedence)
+	}
+	for ok := true; ok; ok = p.match(text.Comma) {
+		op, err := p.expect(text.Operator)("expected operator")
+		if err != nil {
+			p.error(op, err)
+			return nil
+		}
+		switch {
+		case text.ReservedOp(op):
+			p.errorf(op, "cannot declare fixity of reserved operator '%s'", op.Text)
+		case declared[op.Text]:
+			p.errorf(op, "fixity of operator '%s' declared twice", op.Text)
+		case valid:
+			p.fixities[op.Text] = fixityOfDecl(decl)
+		}
+		declared[op.Text] = true
+		decl.Operators = append(decl.Operators, op)
+	}
+	return
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Types
+
+// Function types are right associative: A => B => C is A => (B => C), and take
+// their parameters either alone or parenthesized: (A, B) => C. Parenthesized
+// types that aren't parameters are tuple types, () being the unit type.
+func (p *Parser) typ() (typ ast.Type) {
+	mark := p.mark()
+	defer func() { p.wrap(mark, typ) }()
+	if !p.eof() && text.Lpar(p.lookahead()) {
+		lpar := p.advance()
+		types := p.types()
+		if types == nil {
+			return nil
+		}
+		if p.match(text.Arrow) {
+			return p.funcType(types)
+		}
+		return tupleType(lpar, types)
+	}
+	param := p.appliedType()
+	if param == nil || !p.match(text.Arrow) {
+		return param
+	}
+	return p.funcType([]ast.Type{param})
+}
+
+func (p *Parser) funcType(params []ast.Type) ast.Type {
+	arrow := p.previous()
+	result := p.typ()
+	if result == nil {
+		return nil
+	}
+	return &ast.FuncType{
+		Params: params,
+		Arrow:  arrow,
+		Result: result,
+	}
+}
+
+// A comma separated list of types, closed by ')'
+func (p *Parser) types() (types []ast.Type) {
+	types = []ast.Type{}
+	if p.match(text.Rpar) {
+		return
+	}
+	for ok := true; ok; ok = p.match(text.Comma) {
+		typ := p.typ()
+		if typ == nil {
+			return nil
+		}
+		types = append(types, typ)
+	}
+	if rpar, err := p.expect(text.Rpar)("expected ')' after types"); err != nil {
+		p.error(rpar, err)
+		return nil
+	}
+	return
+}
+
+func (p *Parser) appliedType() ast.Type {
+	mark := p.mark()
+	typ := p.atomType()
+	if typ == nil || !p.match(text.Lbrk) {
+		return typ
+	}
+	applied := &ast.AppliedType{
+		Type: typ,
+	}
+	for ok := true; ok; ok = p.match(text.Comma) {
+		arg := p.typ()
+		if arg == nil {
+			return nil
+		}
+		applied.Args = append(applied.Args, arg)
+	}
+	if rbrk, err := p.expect(text.Rbrk)("expected ']' after type arguments"); err != nil {
+		p.error(rbrk, err)
+		return nil
+	}
+	p.wrap(mark, applied)
+	return applied
+}
+
+// Parentheses around a single type only group it
+func tupleType(lpar text.Token, types []ast.Type) ast.Type {
+	if len(types) == 1 {
+		return types[0]
+	}
+	return &ast.TupleType{
+		Lpar:  lpar,
+		Elems: types,
+	}
+}
+
+func (p *Parser) atomType() ast.Type {
+	switch mark := p.mark(); {
+	case p.match(text.Identifier):
+		typ := &ast.NamedType{
+			Name: p.previous(),
+		}
+		p.wrap(mark, typ)
+		return typ
+	case p.match(text.Lpar):
+		// an arrow after the parentheses belongs to the enclosing type
+		lpar := p.previous()
+		types := p.types()
+		if types == nil {
+			return nil
+		}
+		typ := tupleType(lpar, types)
+		p.wrap(mark, typ)
+		return typ
+	}
+	p.errorf(p.lookahead(), "expected type, found %s", describe(p.lookahead()))
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Patterns
+
+// A constructor applied to argument patterns, or a single atomic pattern
+func (p *Parser) pattern() (pattern ast.Pattern) {
+	mark := p.mark()
+	defer func() { p.wrap(mark, pattern) }()
+	if p.eof() || !text.Identifier(p.lookahead()) || !isConstructorName(p.lookahead()) {
+		return p.atomPattern()
+	}
+	constructor := &ast.ConstructorPattern{
+		Name: p.advance(),
+	}
+	for p.isPatternStart() {
+		arg := p.atomPattern()
+		if arg == nil {
+			return nil
+		}
+		constructor.Args = append(constructor.Args, arg)
+	}
+	return constructor
+}
+
+func (p *Parser) isPatternStart() bool {
+	if p.eof() {
+		return false
+	}
+	switch token := p.lookahead(); {
+	case text.Identifier(token), text.Literal(token), text.Lpar(token), text.Lbrk(token):
+		return true
+	case text.Minus(token):
+		return text.Integer(p.peek(1)) || text.Float(p.peek(1))
+	}
+	return false
 }
 
-func NewParser(path string) *Parser {
-	p := &Parser{
-		path:    path,
-		Scanner: NewScanner(path),
-	}
-	for token := p.Scanner.Scan(); !text.Eof(token); token = p.Scanner.Scan() {
-		fmt.Println(token.String())
+func (p *Parser) atomPattern() (pattern ast.Pattern) {
+	mark := p.mark()
+	defer func() { p.wrap(mark, pattern) }()
+	switch {
+	case p.match(text.Underscore):
+		return &ast.WildcardPattern{
+			Token: p.previous(),
+		}
+	case p.match(text.Identifier):
+		if isConstructorName(p.previous()) {
+			return &ast.ConstructorPattern{
+				Name: p.previous(),
+			}
+		}
+		return &ast.VarPattern{
+			Name: p.previous(),
+		}
+	case p.match(text.Literal):
+		return &ast.LiteralPattern{
+			Token: p.previous(),
+		}
+	case !p.eof() && text.Minus(p.lookahead()) && (text.Integer(p.peek(1)) || text.Float(p.peek(1))):
+		p.advance()
+		return &ast.LiteralPattern{
+			Token:    p.advance(),
+			Negative: true,
+		}
+	case p.match(text.Lpar):
+		return p.tuplePattern()
+	case p.match(text.Lbrk):
+		return p.listPattern()
 	}
-	p.tokens = &p.Scanner.tokens
-	return p
+	p.errorf(p.lookahead(), "expected pattern, found %s", describe(p.lookahead()))
+	return nil
 }
 
-func (p *Parser) error(token text.Token, err error) {
-	p.Logs = append(p.Logs, Log{
-		Path:    p.path,
-		Level:   LogError,
-		Message: err.Error(),
-		Pos:     token.Pos,
-	})
+func (p *Parser) listPattern() ast.Pattern {
+	list := &ast.ListPattern{
+		Lbrk: p.previous(),
+	}
+	for !p.eof() && !text.Rbrk(p.lookahead()) {
+		elem := p.pattern()
+		if elem == nil {
+			return nil
+		}
+		list.Elems = append(list.Elems, elem)
+		if !p.match(text.Comma) {
+			break
+		}
+	}
+	if rbrk, err := p.expect(text.Rbrk)("expected ',' or ']' in list pattern"); err != nil {
+		p.error(rbrk, err)
+		return nil
+	}
+	return list
 }
 
-func (p *Parser) errorf(token text.Token, message string, args ...interface{}) {
-	p.Logs = append(p.Logs, Log{
-		Path:    p.path,
-		Level:   LogError,
-		Message: fmt.Sprintf(message, args...),
-		Pos:     token.Pos,
-	})
+// A parenthesized pattern, or a tuple pattern when it holds either none or more
+// than one pattern
+func (p *Parser) tuplePattern() ast.Pattern {
+	tuple := &ast.TuplePattern{
+		Lpar: p.previous(),
+	}
+	if !p.match(text.Rpar) {
+		for ok := true; ok; ok = p.match(text.Comma) {
+			elem := p.pattern()
+			if elem == nil {
+				return nil
+			}
+			tuple.Elems = append(tuple.Elems, elem)
+		}
+		rpar, err := p.expect(text.Rpar)("expected ')' after pattern")
+		if err != nil {
+			p.error(rpar, err)
+			return nil
+		}
+	}
+	if len(tuple.Elems) == 1 {
+		return tuple.Elems[0]
+	}
+	return tuple
 }
 
 ////////////////////////////////////////////////////////////////////////////////
+// Expressions
 
-func (p *Parser) eof() bool {
-	return p.current >= len(*p.tokens)
+// Expressions that fail to parse are replaced by an ast.BadExpr spanning the
+// tokens read so far, so the tree stays usable
+func (p *Parser) expr() (expr ast.Expr) {
+	start, mark := p.current, p.mark()
+	defer func() { p.wrap(mark, expr) }()
+	switch {
+	case p.isLambdaStart():
+		expr = p.lambda()
+	default:
+		expr = p.binary(0)
+	}
+	if expr == nil {
+		return p.badExpr(start)
+	}
+	return
 }
 
-func (p *Parser) peek(n int) text.Token {
-	if p.current+n >= len(*p.tokens) {
-		return p.previous()
+func (p *Parser) badExpr(start int) *ast.BadExpr {
+	bad := &ast.BadExpr{
+		From: p.peek(start - p.current),
 	}
-	return (*p.tokens)[p.current+n]
+	if p.current > start {
+		bad.To = p.previous()
+	} else {
+		bad.To = bad.From
+	}
+	return bad
 }
 
-func (p *Parser) previous() text.Token {
-	return p.peek(-1)
+func isBadExpr(expr ast.Expr) bool {
+	_, bad := expr.(*ast.BadExpr)
+	return bad
 }
 
-func (p *Parser) lookahead() text.Token {
-	if p.eof() {
-		return p.previous()
-	}
-	return p.peek(0)
+// The expression before the braces of if and match, where Ctor {} isn't a record
+func (p *Parser) condition() ast.Expr {
+	defer p.allowRecords(false)()
+	return p.binary(0)
 }
 
-func (p *Parser) advance() text.Token {
-	if !p.eof() {
-		p.current++
+// Conditionals come either as if c then a else b, or with a block as their
+// consequence: if c { a } else { b }. The alternative may be omitted.
+func (p *Parser) ifExpr() ast.Expr {
+	expr := &ast.IfExpr{
+		Token: p.previous(),
+		Cond:  p.condition(),
 	}
-	return p.previous()
+	if isBadExpr(expr.Cond) {
+		return nil // already reported
+	}
+	switch {
+	case p.match(text.Then):
+		expr.Then = p.expr()
+	case p.match(text.Lbrc):
+		expr.Then = p.block()
+	default:
+		p.errorf(p.lookahead(), "expected 'then' or '{' after condition, found %s", describe(p.lookahead()))
+		return nil
+	}
+	if expr.Then == nil {
+		return nil
+	}
+	if p.match(text.Else) {
+		expr.Else = p.expr()
+	}
+	return expr
 }
 
-func (p *Parser) match(fs ...func(text.Token) bool) bool {
-	if p.eof() {
+// Lambdas start with either a lone parameter or a parenthesized parameter list,
+// followed by '=>'
+func (p *Parser) isLambdaStart() bool {
+	switch {
+	case p.eof():
 		return false
-	}
-	for _, f := range fs {
-		if f(p.lookahead()) {
-			p.advance()
-			return true
+	case text.Identifier(p.lookahead()):
+		return text.Arrow(p.peek(1))
+	case text.Lpar(p.lookahead()):
+		depth := 0
+		for i := p.current; i < len(*p.tokens); i++ {
+			switch token := (*p.tokens)[i]; {
+			case text.Lpar(token):
+				depth++
+			case text.Rpar(token):
+				depth--
+				if depth == 0 {
+					return i+1 < len(*p.tokens) && text.Arrow((*p.tokens)[i+1])
+				}
+			}
 		}
 	}
 	return false
 }
 
-func (p *Parser) expect(fs ...func(text.Token) bool) func(string) (text.Token, error) {
-	return func(message string) (token text.Token, err error) {
-		for _, f := range fs {
-			if f(p.lookahead()) {
-				p.advance()
-				token = p.previous()
-				return
-			}
+// Lambdas extend as far to the right as possible: x => y => x + y is
+// x => (y => (x + y))
+func (p *Parser) lambda() ast.Expr {
+	params := p.params()
+	if params == nil {
+		return nil
+	}
+	arrow := p.advance() // '=>'
+	p.openFunction(params)
+	defer p.closeFunction()
+	return &ast.LambdaExpr{
+		Params: params,
+		Arrow:  arrow,
+		Body:   p.expr(),
+	}
+}
+
+func (p *Parser) matchExpr() ast.Expr {
+	expr := &ast.MatchExpr{
+		Token: p.previous(),
+		Expr:  p.condition(),
+	}
+	if isBadExpr(expr.Expr) {
+		return nil // already reported
+	}
+	if lbrc, err := p.expect(text.Lbrc)("expected '{' after match expression"); err != nil {
+		p.error(lbrc, err)
+		return nil
+	}
+	for mark := p.mark(); p.match(text.Case); mark = p.mark() {
+		c := p.matchCase()
+		if c == nil {
+			return nil
 		}
-		token = p.lookahead()
-		err = errors.New(message)
-		return
+		p.wrap(mark, c)
+		expr.Cases = append(expr.Cases, c)
+	}
+	if len(expr.Cases) == 0 {
+		p.errorf(p.lookahead(), "expected at least one case in match expression")
 	}
+	if rbrc, err := p.expect(text.Rbrc)("expected 'case' or '}' in match expression"); err != nil {
+		p.error(rbrc, err)
+		return nil
+	}
+	return expr
 }
 
-////////////////////////////////////////////////////////////////////////////////
+func (p *Parser) matchCase() *ast.CaseAST {
+	c := &ast.CaseAST{
+		Token:   p.previous(),
+		Pattern: p.pattern(),
+	}
+	if c.Pattern == nil {
+		return nil
+	}
+	p.openScope()
+	defer p.closeScope()
+	p.declarePattern(c.Pattern)
+	if p.match(text.If) {
+		c.Guard = p.binary(0)
+	}
+	if arrow, err := p.expect(text.Arrow)("expected '=>' after case pattern"); err != nil {
+		p.error(arrow, err)
+		return nil
+	}
+	c.Body = p.expr()
+	return c
+}
 
-func (p *Parser) sync() {
-	p.advance()
+// Precedence climbing over the binary operators, every operand being a unary
+// expression
+func (p *Parser) binary(minPrecedence int) (expr ast.Expr) {
+	mark := p.mark()
+	expr = p.unary()
 	for !p.eof() {
-		if text.Semicolon(p.previous()) {
+		op := p.lookahead()
+		f, ok := p.fixityOf(op)
+		if !ok || f.precedence < minPrecedence {
 			return
 		}
-		switch token := p.lookahead(); {
-		case text.Module(token):
-			return
-		case text.Import(token):
+		// in blocks, a line starting with a prefix operator is a new statement
+		if p.lineBreaks && text.UnaryOp(op) && op.Line != p.previous().Line {
 			return
-		case text.Def(token):
-			return
-		case text.Struct(token):
-			return
-		case text.Trait(token):
-			return
-		case text.Let(token):
-			return
-		case text.Match(token):
-			return
-		case text.Case(token):
-			return
-		case text.Return(token):
-			return
-		default:
-			p.advance()
+		}
+		p.advance()
+		next := f.precedence + 1
+		if f.associativity == rightAssoc {
+			next = f.precedence
+		}
+		expr = &ast.BinaryExpr{
+			Left:  expr,
+			Op:    op,
+			Right: p.binary(next),
+		}
+		p.wrap(mark, expr)
+		if f.associativity == nonAssoc && !p.eof() {
+			if g, ok := p.fixityOf(p.lookahead()); ok && g.precedence == f.precedence {
+				p.errorf(p.lookahead(), "operator '%s' is non-associative, use parentheses", p.lookahead().Text)
+			}
 		}
 	}
+	return
 }
 
-////////////////////////////////////////////////////////////////////////////////
+func (p *Parser) unary() (expr ast.Expr) {
+	mark := p.mark()
+	defer func() { p.wrap(mark, expr) }()
+	if !p.eof() && text.Minus(p.lookahead()) && (text.Integer(p.peek(1)) || text.Float(p.peek(1))) {
+		return p.literal()
+	}
+	if p.match(text.UnaryOp) {
+		op := p.previous()
+		return &ast.UnaryExpr{
+			Op:   op,
+			Expr: p.unary(),
+		}
+	}
+	return p.application()
+}
 
-func (p *Parser) Parse() ast.AST {
-	return p.compilationUnit()
+// Left associative function application by juxtaposition: f x y is (f x) y
+func (p *Parser) application() (expr ast.Expr) {
+	start, mark := p.current, p.mark()
+	expr = p.postfix()
+	// stop as soon as an operand fails without consuming any token
+	for p.current > start && p.isArgumentStart() {
+		start = p.current
+		expr = &ast.ApplyExpr{
+			Fn:  expr,
+			Arg: p.postfix(),
+		}
+		p.wrap(mark, expr)
+	}
+	return
 }
 
-func (p *Parser) compilationUnit() (module *ast.ModuleAST) {
-	if !p.match(text.Module) {
-		p.errorf(p.peek(0), "expected module declaration")
-		return &ast.ModuleAST{
-			Name: "<invalid>",
+// Field accesses, qualified names, record construction and record update bind
+// tighter than application
+func (p *Parser) postfix() (expr ast.Expr) {
+	start, mark := p.current, p.mark()
+	defer func() { p.wrap(mark, expr) }()
+	expr = p.primary()
+	for {
+		switch {
+		case p.match(text.Dot):
+			dot := p.previous()
+			name, err := p.expect(text.Identifier)("expected field name after '.'")
+			if err != nil {
+				p.error(name, err)
+				return p.badExpr(start)
+			}
+			expr = &ast.FieldExpr{
+				Expr: expr,
+				Dot:  dot,
+				Name: name,
+			}
+			p.wrap(mark, expr)
+		case p.isRecordStart(expr):
+			p.advance() // '{'
+			fields := p.fieldInits()
+			if fields == nil {
+				return p.badExpr(start)
+			}
+			if isConstructorRef(expr) {
+				expr = &ast.StructExpr{
+					Type:   expr,
+					Fields: fields,
+				}
+			} else {
+				expr = &ast.UpdateExpr{
+					Expr:   expr,
+					Fields: fields,
+				}
+			}
+			p.wrap(mark, expr)
+		default:
+			return
 		}
 	}
-	moduleToken := p.previous()
-	moduleName, err := p.expect(text.Identifier)("expected module name after 'module' token")
-	if err != nil {
-		p.error(moduleToken, err)
+}
+
+// Braces following an expression hold field initializers when they start with
+// name = ..., or may be empty after a struct name
+func (p *Parser) isRecordStart(expr ast.Expr) bool {
+	if p.noRecords || p.eof() || !text.Lbrc(p.lookahead()) {
+		return false
 	}
-	module = &ast.ModuleAST{
-		Tokens: []text.Token{moduleToken, moduleName},
-		Name:   moduleName.Text,
+	if text.Identifier(p.peek(1)) && text.Assign(p.peek(2)) {
+		return true
+	}
+	return isConstructorRef(expr) && text.Rbrc(p.peek(1))
+}
+
+func isConstructorRef(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.ConstructorExpr:
+		return true
+	case *ast.FieldExpr:
+		return isConstructorName(expr.Name)
+	}
+	return false
+}
+
+func (p *Parser) fieldInits() (fields []*ast.FieldInitAST) {
+	fields = []*ast.FieldInitAST{}
+	for !p.eof() && !text.Rbrc(p.lookahead()) {
+		mark := p.mark()
+		name, err := p.expect(text.Identifier)("expected field name")
+		if err != nil {
+			p.error(name, err)
+			return nil
+		}
+		if assign, err := p.expect(text.Assign)("expected '=' after field name"); err != nil {
+			p.error(assign, err)
+			return nil
+		}
+		field := &ast.FieldInitAST{
+			Name: name,
+			Expr: p.expr(),
+		}
+		p.wrap(mark, field)
+		fields = append(fields, field)
+		if !p.match(text.Comma) {
+			break
+		}
 	}
-	for p.def(module) != nil {
+	if rbrc, err := p.expect(text.Rbrc)("expected ',' or '}' after field initializer"); err != nil {
+		p.error(rbrc, err)
+		return nil
 	}
 	return
 }
 
-func (p *Parser) def(module *ast.ModuleAST) (decl *ast.DeclAST) {
+// Arguments must start on the same line as the expression they are applied to,
+// a line break ends the application
+func (p *Parser) isArgumentStart() bool {
+	if p.eof() || p.lookahead().Line != p.previous().Line {
+		return false
+	}
+	switch token := p.lookahead(); {
+	case text.Identifier(token), text.Literal(token), text.StringHead(token), text.Lpar(token), text.Lbrk(token):
+		return true
+	case text.If(token), text.Match(token):
+		return true
+	}
+	return false
+}
+
+func (p *Parser) primary() (expr ast.Expr) {
+	start, mark := p.current, p.mark()
+	defer func() {
+		if expr == nil {
+			expr = p.badExpr(start)
+		}
+		p.wrap(mark, expr)
+	}()
 	switch {
-	case p.match(text.Def):
-		decl = &ast.DeclAST{}
-		defName, err := p.expect(text.Identifier)("expected identifier")
-		if err != nil {
-			p.error(defName, err)
-			return
+	case p.match(text.Identifier):
+		if isConstructorName(p.previous()) {
+			expr = &ast.ConstructorExpr{
+				Token: p.previous(),
+				Name:  p.previous().Text,
+			}
+		} else {
+			expr = &ast.IdentExpr{
+				Token: p.previous(),
+				Name:  p.previous().Text,
+			}
 		}
-		decl.Name = defName.Text
-		if p.match(text.Assign) {
-			decl.Expr = p.literal()
+	case p.match(text.Lpar):
+		expr = p.tuple()
+	case p.match(text.Lbrk):
+		expr = p.list()
+	case p.match(text.Lbrc):
+		if p.isMapStart() {
+			expr = p.mapExpr()
 		} else {
-			fmt.Println(p.peek(0))
+			expr = p.block()
+		}
+	case p.match(text.Match):
+		expr = p.matchExpr()
+	case p.match(text.If):
+		// the else branch extends as far right as possible, like a lambda body
+		expr = p.ifExpr()
+	default:
+		expr = p.literal()
+	}
+	return
+}
+
+// A parenthesized expression, or a tuple when it holds either none or more than
+// one expression: (), (a, b)
+func (p *Parser) tuple() ast.Expr {
+	lpar := p.previous()
+	elems := p.exprs(text.Rpar, "expected ',' or ')' in parenthesized expression")
+	if elems == nil {
+		return nil
+	}
+	if len(elems) == 1 && !text.Comma(p.peek(-2)) {
+		return &ast.GroupingExpr{
+			Lpar: lpar,
+			Expr: elems[0],
+			Rpar: p.previous(),
+		}
+	}
+	return &ast.TupleExpr{
+		Lpar:  lpar,
+		Elems: elems,
+		Rpar:  p.previous(),
+	}
+}
+
+func (p *Parser) list() ast.Expr {
+	lbrk := p.previous()
+	elems := p.exprs(text.Rbrk, "expected ',' or ']' in list")
+	if elems == nil {
+		return nil
+	}
+	return &ast.ListExpr{
+		Lbrk:  lbrk,
+		Elems: elems,
+		Rbrk:  p.previous(),
+	}
+}
+
+// A comma separated list of expressions, allowing a trailing comma, up to the
+// closing token
+func (p *Parser) exprs(closing func(text.Token) bool, message string) (exprs []ast.Expr) {
+	defer p.breakLines(false)()
+	defer p.allowRecords(true)()
+	exprs = []ast.Expr{}
+	for !p.eof() && !closing(p.lookahead()) {
+		exprs = append(exprs, p.expr())
+		if !p.match(text.Comma) {
+			break
+		}
+	}
+	if token, err := p.expect(closing)(message); err != nil {
+		p.error(token, err)
+		return nil
+	}
+	return
+}
+
+// Braces hold a map rather than a block when they are followed by ':', or when
+// a ':' shows up on the first line of the braces before any statement ends
+func (p *Parser) isMapStart() bool {
+	if p.eof() || text.Let(p.lookahead()) {
+		return false
+	}
+	line := p.lookahead().Line
+	depth := 0
+	for i := p.current; i < len(*p.tokens); i++ {
+		token := (*p.tokens)[i]
+		switch {
+		case token.Line != line:
+			return false
+		case text.Lpar(token), text.Lbrk(token), text.Lbrc(token):
+			depth++
+		case depth > 0 && (text.Rpar(token) || text.Rbrk(token) || text.Rbrc(token)):
+			depth--
+		case depth > 0:
+		case text.Colon(token):
+			return true
+		case text.Semicolon(token), text.Rbrc(token), text.Let(token):
+			return false
+		}
+	}
+	return false
+}
+
+// A map literal: {"k": v, ...}, the empty map being {:}
+func (p *Parser) mapExpr() ast.Expr {
+	expr := &ast.MapExpr{
+		Lbrc:    p.previous(),
+		Entries: []*ast.EntryAST{},
+	}
+	defer p.breakLines(false)()
+	defer p.allowRecords(true)()
+	if p.match(text.Colon) {
+		rbrc, err := p.expect(text.Rbrc)("expected '}' to close empty map")
+		if err != nil {
+			p.error(rbrc, err)
+			return nil
+		}
+		expr.Rbrc = rbrc
+		return expr
+	}
+	for !p.eof() && !text.Rbrc(p.lookahead()) {
+		mark := p.mark()
+		key := p.expr()
+		if colon, err := p.expect(text.Colon)("expected ':' after map key"); err != nil {
+			p.error(colon, err)
+			return nil
+		}
+		entry := &ast.EntryAST{
+			Key:   key,
+			Value: p.expr(),
+		}
+		p.wrap(mark, entry)
+		expr.Entries = append(expr.Entries, entry)
+		if !p.match(text.Comma) {
+			break
+		}
+	}
+	rbrc, err := p.expect(text.Rbrc)("expected ',' or '}' in map")
+	if err != nil {
+		p.error(rbrc, err)
+		return nil
+	}
+	expr.Rbrc = rbrc
+	return expr
+}
+
+// A sequence of statements separated by semicolons or line breaks. When the last
+// statement is an expression, it is the value of the block.
+// Sets whether line breaks end statements, returning the function restoring the
+// previous setting
+func (p *Parser) breakLines(lineBreaks bool) func() {
+	saved := p.lineBreaks
+	p.lineBreaks = lineBreaks
+	return func() {
+		p.lineBreaks = saved
+	}
+}
+
+// Sets whether braces after an expression may open a record, returning the
+// function restoring the previous setting
+func (p *Parser) allowRecords(allowed bool) func() {
+	saved := p.noRecords
+	p.noRecords = !allowed
+	return func() {
+		p.noRecords = saved
+	}
+}
+
+func (p *Parser) block() ast.Expr {
+	block := &ast.BlockExpr{
+		Lbrc: p.previous(),
+	}
+	p.openScope()
+	defer p.closeScope()
+	defer p.breakLines(true)()
+	defer p.allowRecords(true)()
+	for !p.eof() && !text.Rbrc(p.lookahead()) {
+		start, mark := p.current, p.mark()
+		stmt := p.stmt()
+		// a statement failing without reading any token would be tried again
+		if stmt == nil || p.current == start {
+			return nil
+		}
+		p.wrap(mark, stmt)
+		block.Stmts = append(block.Stmts, stmt)
+		switch {
+		case p.match(text.Semicolon):
+		case p.eof(), text.Rbrc(p.lookahead()):
+		case p.lookahead().Line != p.previous().Line:
+		default:
+			p.errorf(p.lookahead(), "expected ';' or line break after statement, found %s", describe(p.lookahead()))
+			return nil
+		}
+	}
+	rbrc, err := p.expect(text.Rbrc)("expected '}' to close block")
+	if err != nil {
+		p.error(rbrc, err)
+		return nil
+	}
+	block.Rbrc = rbrc
+	if n := len(block.Stmts); n > 0 {
+		if last, ok := block.Stmts[n-1].(*ast.ExprStmt); ok {
+			block.Stmts = block.Stmts[:n-1]
+			block.Value = last.Expr
+		}
+	}
+	return block
+}
+
+func (p *Parser) stmt() ast.Stmt {
+	switch {
+	case p.match(text.Let):
+		if let := p.let(); let != nil {
+			return let
+		}
+		return nil
+	case p.match(text.Return):
+		return p.returnStmt()
+	case !p.eof() && text.Identifier(p.lookahead()) && text.Walrus(p.peek(1)):
+		return p.assign()
+	}
+	return &ast.ExprStmt{
+		Expr: p.expr(),
+	}
+}
+
+func (p *Parser) let() (let *ast.LetAST) {
+	let = &ast.LetAST{
+		Token:   p.previous(),
+		Mutable: p.match(text.Mut),
+	}
+	name, err := p.expect(text.Identifier)("expected identifier after 'let'")
+	if err != nil {
+		p.error(name, err)
+		return nil
+	}
+	let.Name = name
+	if !p.eof() && text.Lpar(p.lookahead()) {
+		// let main () { ... } used to declare functions
+		p.errorf(p.lookahead(), "let bindings take no parameters, declare functions with 'def %s(...) = ...'", name.Text)
+		return nil
+	}
+	if p.match(text.Colon) {
+		if let.Type = p.typ(); let.Type == nil {
+			return nil
 		}
-		module.Decls = append(module.Decls, decl)
 	}
+	if assign, err := p.expect(text.Assign)("expected '=' in let binding"); err != nil {
+		p.error(assign, err)
+		return nil
+	}
+	let.Expr = p.expr()
+	p.declare(name, let.Mutable)
 	return
 }
 
+// Reassignment of a mutable binding: x := x + 1
+func (p *Parser) assign() ast.Stmt {
+	stmt := &ast.AssignStmt{
+		Name:   p.advance(),
+		Walrus: p.advance(),
+	}
+	switch mutable, found := p.lookup(stmt.Name); {
+	case !found:
+		p.errorf(stmt.Name, "cannot assign to undeclared name '%s'", stmt.Name.Text)
+	case !mutable:
+		p.errorf(stmt.Name, "cannot assign to immutable binding '%s'", stmt.Name.Text)
+	}
+	stmt.Expr = p.expr()
+	return stmt
+}
+
+// Early return from the enclosing function, the value may be omitted at the end
+// of a statement
+func (p *Parser) returnStmt() ast.Stmt {
+	stmt := &ast.ReturnStmt{
+		Token: p.previous(),
+	}
+	if p.functionDepth == 0 {
+		p.errorf(stmt.Token, "'return' outside of a function")
+	}
+	switch {
+	case p.eof(), text.Rbrc(p.lookahead()), text.Semicolon(p.lookahead()):
+	case p.lookahead().Line != stmt.Token.Line:
+	default:
+		stmt.Expr = p.expr()
+	}
+	return stmt
+}
+
 func (p *Parser) literal() (expr ast.Expr) {
 	switch {
 	case p.match(text.Minus):
@@ -205,7 +1773,7 @@ func (p *Parser) literal() (expr ast.Expr) {
 				Value: -value,
 			}
 		default:
-			p.errorf(p.lookahead(), "expected integer or float literal, instead found '%s'", p.lookahead().Text)
+			p.errorf(p.lookahead(), "expected integer or float literal, instead found %s", describe(p.lookahead()))
 		}
 		return
 	case p.match(text.Boolean):
@@ -232,13 +1800,54 @@ func (p *Parser) literal() (expr ast.Expr) {
 			Value: value,
 		}
 		return
+	case p.match(text.Char):
+		token := p.previous()
+		value, _ := utf8.DecodeRuneInString(token.Text)
+		expr = &ast.CharExpr{
+			Token: token,
+			Value: value,
+		}
+		return
+	case p.match(text.Symbol):
+		expr = &ast.SymbolExpr{
+			Token: p.previous(),
+			Name:  p.previous().Text,
+		}
+		return
 	case p.match(text.String):
 		expr = &ast.StringExpr{
 			Token: p.previous(),
 			Value: p.previous().Text,
 		}
 		return
+	case !p.eof() && text.StringHead(p.lookahead()):
+		expr = p.interpolatedString()
+		return
 	}
-	p.errorf(p.lookahead(), "expected expression, found '%s'", p.lookahead().Text)
+	p.errorf(p.lookahead(), "expected expression, found %s", describe(p.lookahead()))
 	return
 }
+
+// The scanner splits interpolated strings around their embedded expressions, so
+// "a${x}b$y" comes as the head "a", x, the middle "b", y and the empty tail
+func (p *Parser) interpolatedString() ast.Expr {
+	expr := &ast.InterpolatedStringExpr{}
+	defer p.breakLines(false)()
+	defer p.allowRecords(true)()
+	for mark := p.mark(); ; mark = p.mark() {
+		if !p.match(text.StringHead, text.StringMid, text.StringTail) {
+			p.errorf(p.lookahead(), "expected '}' to close string interpolation, found %s", describe(p.lookahead()))
+			return nil
+		}
+		segment := &ast.StringExpr{
+			Token: p.previous(),
+			Value: p.previous().Text,
+		}
+		p.wrap(mark, segment)
+		expr.Parts = append(expr.Parts, segment)
+		if text.StringTail(segment.Token) {
+			return expr
+		}
+		expr.Parts = append(expr.Parts, p.expr())
+	}
+}
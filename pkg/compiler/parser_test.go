@@ -0,0 +1,282 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Spriithy/rosa/pkg/compiler/ast"
+)
+
+// parse fails the test rather than hanging when the parser stops making progress
+func parse(t *testing.T, source string) []Log {
+	t.Helper()
+	done := make(chan []Log, 1)
+	go func() {
+		_, logs := NewStringParser("test.rosa", source).Parse()
+		done <- logs
+	}()
+	select {
+	case logs := <-done:
+		return logs
+	case <-time.After(5 * time.Second):
+		t.Fatalf("parsing %q didn't terminate", source)
+		return nil
+	}
+}
+
+func TestBlockRecovery(t *testing.T) {
+	tests := []string{
+		"module m\ndef f = { 1\n) }",
+		"module m\ndef f = { let x =\ndef g = 1",
+	}
+	for _, source := range tests {
+		if logs := parse(t, source); len(logs) == 0 {
+			t.Errorf("parsing %q reported no error", source)
+		}
+	}
+}
+
+// Names of the declarations and bindings at the top level of the module
+func topLevelNames(module ast.AST) (names []string) {
+	for _, decl := range module.(*ast.ModuleAST).Decls {
+		switch decl := decl.(type) {
+		case *ast.DeclAST:
+			names = append(names, decl.Name)
+		case *ast.LetAST:
+			names = append(names, decl.Name.Text)
+		}
+	}
+	return
+}
+
+func TestDeclRecovery(t *testing.T) {
+	tests := []struct {
+		source string
+		names  string
+		errors int
+	}{
+		{"module m\ndef\ndef g = 1\ndef h = 2", "[g h]", 1},
+		{"module m\ninfixl\ndef g = 1", "[g]", 1},
+		{"module m\nstruct\ndef g = 1", "[g]", 1},
+		{"module m\nx y\ndef g = 1", "[g]", 1},
+		{"module m\ndef f = {\n let = 3\n let y = 4\n y\n}\ndef g = 1", "[f g]", 1},
+		{"module m\ndef f = 1 then {\n def x = 1\n}\ndef g = 1", "[f g]", 1},
+	}
+	for _, test := range tests {
+		module, logs := NewStringParser("test.rosa", test.source).Parse()
+		if names := fmt.Sprint(topLevelNames(module)); names != test.names {
+			t.Errorf("parsing %q: declared %s, expected %s", test.source, names, test.names)
+		}
+		if len(logs) != test.errors {
+			t.Errorf("parsing %q: expected %d errors, got %v", test.source, test.errors, logs)
+		}
+	}
+}
+
+func TestEndOfFileErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		error  string
+	}{
+		{"", "test.rosa:1:1: error: expected module declaration"},
+		{"module m\ndef f =", "test.rosa:2:8: error: expected expression, found end of file"},
+		{"module m\ndef f = match", "test.rosa:2:14: error: expected expression, found end of file"},
+		{"module m\ndef f = if a", "test.rosa:2:13: error: expected 'then' or '{' after condition, found end of file"},
+	}
+	for _, test := range tests {
+		logs := parse(t, test.source)
+		if len(logs) != 1 || logs[0].AsError().Error() != test.error {
+			t.Errorf("parsing %q: expected only %q, got %v", test.source, test.error, logs)
+		}
+	}
+}
+
+func TestAssignMutability(t *testing.T) {
+	tests := []struct {
+		source string
+		valid  bool
+	}{
+		{"module m\nlet mut z = 0\ndef f = { z := 2 }", true},
+		{"module m\ndef f = { z := 2 }\nlet mut z = 0", true},
+		{"module m\ndef f = { let mut x = 1; x := 2 }", true},
+		{"module m\ndef f = { z := 2 }\nlet z = 0", false},
+		{"module m\ndef f = { f := 2 }", false},
+		{"module m\ndef f = { g := 2 }\ndef g = 1", false},
+		{"module m\ndef f = { x := 2 }", false},
+		{"module m\ndef f = { let x = 1; x := 2 }", false},
+		{"module m\ndef f x = { x := 2 }", false},
+	}
+	for _, test := range tests {
+		logs := parse(t, test.source)
+		if valid := len(logs) == 0; valid != test.valid {
+			t.Errorf("parsing %q: expected valid to be %t, got logs %v", test.source, test.valid, logs)
+		}
+	}
+}
+
+func TestFixityDecl(t *testing.T) {
+	tests := []struct {
+		source     string
+		op         string
+		registered bool
+		errors     int
+	}{
+		{"module m\ninfixr 6 +++", "+++", true, 0},
+		{"module m\ndef f = a +++ b\ninfixl 5 +++", "+++", true, 0},
+		{"module m\ninfix 99 +++", "+++", false, 1},
+		{"module m\ninfixl 5 =", "=", false, 1},
+		{"module m\ninfixl 5 +++, +++", "+++", true, 1},
+		{"module m\ninfixl 5 +++\ninfixr 6 +++", "+++", true, 1},
+	}
+	for _, test := range tests {
+		p := NewStringParser("test.rosa", test.source)
+		_, logs := p.Parse()
+		if _, registered := p.fixities[test.op]; registered != test.registered {
+			t.Errorf("parsing %q: expected '%s' registered to be %t", test.source, test.op, test.registered)
+		}
+		if len(logs) != test.errors {
+			t.Errorf("parsing %q: expected %d errors, got %v", test.source, test.errors, logs)
+		}
+	}
+}
+
+func TestImportFixities(t *testing.T) {
+	imported, _ := NewStringParser("a.rosa", "module a\ninfixr 1 >>=, <|>").Parse()
+	tests := []struct {
+		source string
+		ops    []string
+	}{
+		{"module m\nimport a", []string{">>=", "<|>"}},
+		{"module m\nimport a.{>>=}", []string{">>="}},
+		{"module m\nimport b", nil},
+	}
+	for _, test := range tests {
+		p := NewStringParser("test.rosa", test.source)
+		p.Import(imported.(*ast.ModuleAST))
+		p.Parse()
+		if len(p.imported) != len(test.ops) {
+			t.Errorf("parsing %q: expected fixities of %v, got %v", test.source, test.ops, p.imported)
+		}
+		for _, op := range test.ops {
+			if f, ok := p.imported[op]; !ok || f.associativity != rightAssoc || f.precedence != 1 {
+				t.Errorf("parsing %q: expected fixity of '%s' to be imported", test.source, op)
+			}
+		}
+	}
+}
+
+type exprTest struct {
+	expr   string
+	parsed string
+}
+
+// Parses every expression as the body of a declaration, expecting no error
+func runExprTests(t *testing.T, tests []exprTest) {
+	t.Helper()
+	for _, test := range tests {
+		module, logs := NewStringParser("test.rosa", "module m\ndef f = "+test.expr).Parse()
+		if len(logs) > 0 {
+			t.Errorf("parsing %q: unexpected errors %v", test.expr, logs)
+			continue
+		}
+		decl := module.(*ast.ModuleAST).Decls[0].(*ast.DeclAST)
+		if parsed := decl.Expr.Accept(ast.AstPrinter{}); parsed != test.parsed {
+			t.Errorf("parsing %q: got %s, expected %s", test.expr, parsed, test.parsed)
+		}
+	}
+}
+
+func TestConditionalOperands(t *testing.T) {
+	tests := []exprTest{
+		{"1 + if a then b else c", "(+ 1 (if a b c))"},
+		{"h if a then b else c + 1", "(apply h (if a b (+ c 1)))"},
+		{"2 * match x { case _ => 0 } + 1", "(+ (* 2 (match x (case _ 0))) 1)"},
+		{"if a then if b then 1 else 2", "(if a (if b 1 2))"},
+	}
+	runExprTests(t, tests)
+}
+
+func TestModuleName(t *testing.T) {
+	tests := []struct {
+		source string
+		name   string
+	}{
+		{"module a.b", "a.b"},
+		{`module "a.b"`, "a.b"},
+		{"module", "<invalid>"},
+		{`module ""`, "<invalid>"},
+		{`module "a.1"`, "<invalid>"},
+		{"module a.", "<invalid>"},
+		{"def f = 1", "<invalid>"},
+	}
+	for _, test := range tests {
+		module, _ := NewStringParser("test.rosa", test.source).Parse()
+		if name := module.(*ast.ModuleAST).Name; name != test.name {
+			t.Errorf("parsing %q: module named %q, expected %q", test.source, name, test.name)
+		}
+	}
+}
+
+func TestLetParameters(t *testing.T) {
+	logs := parse(t, "module m\nlet main () {\n  let x = 4\n}")
+	if len(logs) == 0 || logs[0].Pos.Line != 2 || logs[0].Pos.Column != 10 {
+		t.Errorf("expected an error on the parameters of let, got %v", logs)
+	}
+}
+
+func TestBlockLineBreaks(t *testing.T) {
+	tests := []exprTest{
+		{"{\n let x = a\n !x\n}", "(block (let x a) (! x))"},
+		{"{ g x\n -1 }", "(block (apply g x) -1)"},
+		{"{ a\n + b }", "(block (+ a b))"},
+		{"{ (a\n - b) }", "(block (group (- a b)))"},
+		{"a\n - b", "(- a b)"},
+	}
+	runExprTests(t, tests)
+}
+
+func TestInterpolatedString(t *testing.T) {
+	tests := []exprTest{
+		{`"x${y + 1}z$w"`, `(interpolate "x" (+ y 1) "z" w "")`},
+		{`"$x$y"`, `(interpolate "" x "" y "")`},
+		{`"a${"b${c}"}d"`, `(interpolate "a" (interpolate "b" c "") "d")`},
+		{`"a${ {1} }"`, `(interpolate "a" (block 1) "")`},
+	}
+	runExprTests(t, tests)
+	logs := parse(t, "module m\ndef f = \"a${x\ndef g = 1")
+	if len(logs) == 0 || logs[0].Message != "unclosed string interpolation" {
+		t.Errorf("expected an unclosed string interpolation, got %v", logs)
+	}
+}
+
+func TestParenthesizedTypes(t *testing.T) {
+	tests := []struct {
+		decl   string
+		parsed string
+	}{
+		{"type T = Pair (A, B) | Fn (A => B) | U ()", "(type T (Pair (A, B)) (Fn (A => B)) (U ()))"},
+		{"type T = Box ((A))", "(type T (Box A))"},
+		{"def f[T: (A, B)](x: T) = x", "(decl f[T: (A, B)] (x: T) x)"},
+		{"def f(x: (A, B) => C, y: Option[(A, B)]) = x", "(decl f (x: ((A, B) => C), y: Option[(A, B)]) x)"},
+	}
+	for _, test := range tests {
+		module, logs := NewStringParser("test.rosa", "module m\n"+test.decl).Parse()
+		if len(logs) > 0 {
+			t.Errorf("parsing %q: unexpected errors %v", test.decl, logs)
+			continue
+		}
+		if parsed := module.(*ast.ModuleAST).Decls[0].Accept(ast.AstPrinter{}); strings.TrimSpace(parsed) != test.parsed {
+			t.Errorf("parsing %q: got %s, expected %s", test.decl, parsed, test.parsed)
+		}
+	}
+}
+
+func TestConditionRecords(t *testing.T) {
+	runExprTests(t, []exprTest{
+		{"if x == None {} else {}", "(if (== x None) (block) (block))"},
+		{"if x == (None {}) { 1 }", "(if (== x (group (struct None))) (block 1))"},
+		{"match p { case _ => P {} }", "(match p (case _ (struct P)))"},
+	})
+}
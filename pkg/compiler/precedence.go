@@ -0,0 +1,117 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/Spriithy/rosa/pkg/compiler/ast"
+	"github.com/Spriithy/rosa/pkg/compiler/text"
+)
+
+type associativity int
+
+const (
+	leftAssoc associativity = iota
+	rightAssoc
+	nonAssoc
+)
+
+type fixity struct {
+	precedence    int
+	associativity associativity
+}
+
+// Precedence of the logical and comparison operators, from loosest to tightest.
+// Arithmetic and bitwise operators get theirs from their first character.
+var binaryFixities = map[string]fixity{
+	"||": {2, leftAssoc},
+	"&&": {3, leftAssoc},
+	"==": {7, nonAssoc},
+	"!=": {7, nonAssoc},
+	"<":  {8, nonAssoc},
+	"<=": {8, nonAssoc},
+	">":  {8, nonAssoc},
+	">=": {8, nonAssoc},
+}
+
+// Precedence of any other operator, given by its first character. Operators
+// made of special characters only bind tighter than all of these.
+var firstRunePrecedences = map[rune]int{
+	'|': 4,
+	'^': 5,
+	'&': 6,
+	'=': 7,
+	'!': 7,
+	'<': 8,
+	'>': 8,
+	':': 9,
+	'+': 10,
+	'-': 10,
+	'*': 11,
+	'/': 11,
+	'%': 11,
+}
+
+const (
+	assignmentPrecedence = 1
+	specialPrecedence    = 12
+)
+
+func (p *Parser) fixityOf(token text.Token) (f fixity, ok bool) {
+	if !text.Operator(token) || text.ReservedOp(token) {
+		return
+	}
+	if f, ok = p.fixities[token.Text]; ok {
+		return
+	}
+	if f, ok = p.imported[token.Text]; ok {
+		return
+	}
+	if f, ok = binaryFixities[token.Text]; ok {
+		return
+	}
+	return operatorFixity(token.Text), true
+}
+
+// Scala-like fixity of an operator: operators ending in '=' (except
+// comparisons) bind the loosest, others by their first character, and
+// operators ending in ':' associate to the right.
+func operatorFixity(op string) (f fixity) {
+	switch first := []rune(op)[0]; {
+	case isAssignmentOperator(op):
+		f.precedence = assignmentPrecedence
+	case firstRunePrecedences[first] > 0:
+		f.precedence = firstRunePrecedences[first]
+	default:
+		f.precedence = specialPrecedence
+	}
+	if strings.HasSuffix(op, ":") {
+		f.associativity = rightAssoc
+	}
+	return
+}
+
+func associativityOf(keyword text.Token) associativity {
+	switch keyword.Text {
+	case "infixl":
+		return leftAssoc
+	case "infixr":
+		return rightAssoc
+	default:
+		return nonAssoc
+	}
+}
+
+func fixityOfDecl(decl *ast.FixityDecl) fixity {
+	return fixity{
+		precedence:    decl.Precedence,
+		associativity: associativityOf(decl.Token),
+	}
+}
+
+func isAssignmentOperator(op string) bool {
+	switch op {
+	case "<=", ">=", "!=":
+		return false
+	}
+	return strings.HasSuffix(op, "=") && !strings.HasPrefix(op, "=")
+}
@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strings"
@@ -11,18 +12,29 @@ import (
 )
 
 type Scanner struct {
-	path        string
-	source      []rune
-	tokens      []text.Token
-	start       int
-	current     int
-	line        int
-	lastNewline int
-	Logs        []Log
+	path   string
+	source []rune
+	reader *text.RuneSliceReader
+	tokens []text.Token
+	Logs   []Log
+
+	// where the current token starts, as it may span several lines
+	start    int
+	startPos text.Pos
+
+	// unicode escapes are read as they are in literals and comments, which
+	// decode their own escapes
+	verbatim bool
 
 	openComments int
 	parens       stack
 	tokenData    strings.Builder
+	keepTrivia   bool
+
+	// set between the segments of an interpolated string and the identifier
+	// embedded by $name, since they are scanned by successive calls to next
+	interpolatedIdent bool
+	resumeString      bool
 }
 
 type tokenStack []text.Token
@@ -61,31 +73,40 @@ func (s *tokenStack) isEmpty() bool {
 	return len(*s) == 0
 }
 
-func fileExists(path string) bool {
-	info, err := os.Stat(path)
-	if os.IsNotExist(err) {
-		return false
+// NewScanner reads the whole source from r, name being the file name reported
+// in positions and logs
+func NewScanner(name string, r io.Reader) (*Scanner, error) {
+	source, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
 	}
-	return !info.IsDir()
+	return NewStringScanner(name, string(source)), nil
 }
 
-func NewScanner(path string) (scanner *Scanner) {
-	if !fileExists(path) {
-		fmt.Printf("error: %s doesn't exist\n", path)
-		return
+func NewStringScanner(name, source string) *Scanner {
+	s := &Scanner{
+		path:   name,
+		parens: new(tokenStack),
 	}
-	source, err := ioutil.ReadFile(path)
+	s.reader = text.StringRuneSliceReader(source, s.readerError)
+	s.reader.DecodeUnicodeIf(func() bool { return !s.verbatim })
+	s.source = s.reader.Source()
+	return s
+}
+
+func OpenScanner(path string) (*Scanner, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		fmt.Printf("error: failed to open %s\n", path)
-		return
+		return nil, fmt.Errorf("failed to open source: %w", err)
 	}
-	scanner = &Scanner{
-		path:   path,
-		line:   1,
-		source: []rune(string(source)),
-		parens: new(tokenStack),
-	}
-	return
+	defer file.Close()
+	return NewScanner(path, file)
+}
+
+// KeepTrivia makes the scanner attach whitespace and comments to the tokens
+// they surround, instead of throwing them away
+func (s *Scanner) KeepTrivia() {
+	s.keepTrivia = true
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -108,57 +129,49 @@ func (s *Scanner) syntaxError(pos text.Pos, message string, args ...interface{})
 	})
 }
 
-func (s *Scanner) eof() bool {
-	return s.current >= len(s.source)
-}
-
-func (s *Scanner) col() int {
-	return s.start - s.lastNewline + 1
+// Bad unicode escapes are reported by the reader at some offset of the current
+// line
+func (s *Scanner) readerError(offset int, message string) {
+	pos := s.currentPos()
+	pos.Column = s.reader.ColumnOf(offset)
+	s.syntaxError(pos, message)
 }
 
-func (s *Scanner) currentCol() int {
-	return s.current - s.lastNewline + 1
+func (s *Scanner) eof() bool {
+	return s.reader.EOF()
 }
 
 func (s *Scanner) pos() text.Pos {
-	return text.Pos{
-		FileName: s.path,
-		Line:     s.line,
-		Column:   s.col(),
-	}
+	return s.startPos
 }
 
 func (s *Scanner) currentPos() text.Pos {
 	return text.Pos{
 		FileName: s.path,
-		Line:     s.line,
-		Column:   s.currentCol(),
+		Line:     s.reader.Line(),
+		Column:   s.reader.Column(),
 	}
 }
 
 func (s *Scanner) peek() rune {
-	if s.eof() {
-		return text.SU
-	}
-	return s.source[s.current]
+	return s.reader.Peek(0)
+}
+
+func (s *Scanner) peekAt(n int) rune {
+	return s.reader.Peek(n)
 }
 
 func (s *Scanner) advance() rune {
-	if s.peek() == '\n' {
-		s.lastNewline = s.current + 1
-		s.line++
+	if s.eof() {
+		return text.SU
 	}
-	s.ingest(s.source[s.current])
-	s.current++
-	return s.source[s.current-1]
+	r := s.reader.NextRune()
+	s.ingest(r)
+	return r
 }
 
 func (s *Scanner) skipRune() {
-	if s.peek() == '\n' {
-		s.lastNewline = s.current + 1
-		s.line++
-	}
-	s.current++
+	s.reader.NextRune()
 }
 
 func (s *Scanner) accept(expected ...rune) bool {
@@ -226,8 +239,9 @@ func (s *Scanner) atLeastOne(f fragments.Fragment) bool {
 	return false
 }
 
+// The raw source of the current token
 func (s *Scanner) text() string {
-	return string(s.source[s.start:s.current])
+	return string(s.source[s.start:s.reader.Offset()])
 }
 
 func (s *Scanner) data() string {
@@ -244,53 +258,96 @@ func (s *Scanner) tokenType() text.TokenType {
 
 func (s *Scanner) wrapToken() text.Token {
 	return text.Token{
-		Text:  s.data(),
-		Type:  s.tokenType(),
-		Pos:   s.pos(),
-		Spans: s.start + len(s.text()),
+		Text:   s.data(),
+		Type:   s.tokenType(),
+		Pos:    s.pos(),
+		Offset: s.start,
+		Spans:  s.reader.Offset(),
 	}
 }
 
 func (s *Scanner) wrapTokenAs(typ text.TokenType) text.Token {
 	return text.Token{
-		Text:  s.data(),
-		Type:  typ,
-		Pos:   s.pos(),
-		Spans: s.start + len(s.text()),
+		Text:   s.data(),
+		Type:   typ,
+		Pos:    s.pos(),
+		Offset: s.start,
+		Spans:  s.reader.Offset(),
 	}
 }
 
 func (s *Scanner) wrapTokenWith(typ text.TokenType, data string) text.Token {
 	return text.Token{
-		Text:  data,
-		Type:  typ,
-		Pos:   s.pos(),
-		Spans: s.start + len(s.text()),
+		Text:   data,
+		Type:   typ,
+		Pos:    s.pos(),
+		Offset: s.start,
+		Spans:  s.reader.Offset(),
 	}
 }
 
+// Operators that weren't registered beforehand are user-defined operators, not
+// identifiers
+func (s *Scanner) wrapOperator() text.Token {
+	if typ := s.tokenType(); typ != text.IdentifierType {
+		return s.wrapTokenAs(typ)
+	}
+	return s.wrapTokenAs(text.OperatorType)
+}
+
 func (s *Scanner) Scan() (token text.Token) {
-	if s.eof() {
-		// report all unmatched parens (, [, {
-		for !s.parens.isEmpty() {
-			paren := s.parens.pop()
-			s.syntaxError(paren.Pos, "unmatched %s", paren.Type)
-		}
+	leading := s.trivia(true)
+	if s.eof() && !s.resumeString {
+		s.unmatchedParens()
 		token = text.Token{
-			Type: text.EOF,
-			Pos:  s.currentPos(),
+			Type:    text.EOF,
+			Pos:     s.currentPos(),
+			Offset:  s.reader.Offset(),
+			Spans:   s.reader.Offset(),
+			Leading: leading,
 		}
 		return
 	}
 	token = s.next()
+	if text.Eof(token) {
+		s.unmatchedParens()
+	}
+	token.Leading = leading
+	token.Trailing = s.trivia(false)
 	s.tokens = append(s.tokens, token)
 	return
 }
 
-func (s *Scanner) next() (token text.Token) {
+func (s *Scanner) startToken() {
 	s.tokenData.Reset()
-	s.start = s.current // reset token pos
+	s.start = s.reader.Offset()
+	s.startPos = s.currentPos()
+}
+
+// Reports all unmatched parens (, [, {, and interpolations
+func (s *Scanner) unmatchedParens() {
+	for !s.parens.isEmpty() {
+		paren := s.parens.pop()
+		if text.StringHead(paren) || text.StringMid(paren) {
+			s.syntaxError(paren.Pos, "unclosed string interpolation")
+		} else {
+			s.syntaxError(paren.Pos, "unmatched %s", paren.Type)
+		}
+	}
+}
+
+func (s *Scanner) next() (token text.Token) {
+	s.startToken()
 	switch {
+	case s.resumeString:
+		s.resumeString = false
+		token = s.stringLit(true)
+	case s.interpolatedIdent:
+		s.interpolatedIdent = false
+		s.advance()
+		s.interpolatedName()
+		token = s.wrapTokenAs(text.IdentifierType)
+		s.resumeString = true
 	case s.eof():
 		token = s.wrapTokenWith(text.EOF, s.text())
 	case s.match(' ', '\t', text.CR, text.LF, text.FF):
@@ -306,11 +363,11 @@ func (s *Scanner) next() (token text.Token) {
 		} else {
 			s.ingest('/')
 			s.operatorRest()
-			token = s.wrapToken()
+			token = s.wrapOperator()
 		}
 	case s.acceptIf(text.IsOperatorPart):
 		s.operatorRest()
-		token = s.wrapToken()
+		token = s.wrapOperator()
 	case s.accept('0'):
 		switch {
 		case s.accept('b', 'B'):
@@ -324,6 +381,10 @@ func (s *Scanner) next() (token text.Token) {
 		token = s.wrapTokenAs(text.IntegerLit)
 	case s.acceptIf(text.NonZeroDigit):
 		token = s.number()
+	case s.match('}') && s.isInterpolation():
+		s.skipRune()
+		s.parens.pop()
+		token = s.stringLit(true)
 	case s.acceptIf(text.IsSeparator):
 		token = s.wrapToken()
 		switch {
@@ -340,19 +401,23 @@ func (s *Scanner) next() (token text.Token) {
 			}
 		}
 	case s.match('"'):
+		// no unicode escape is decoded past the opening quote, not even to
+		// tell raw strings apart
 		s.skipRune()
-		if s.match('"') {
+		s.verbatim = true
+		isRaw := s.match('"') && s.peekAt(1) == '"'
+		s.verbatim = false
+		if isRaw {
 			s.skipRune()
-			if s.match('"') {
-				// s.rawString()
-			}
+			s.skipRune()
+			s.rawString()
+			token = s.wrapTokenAs(text.StringLit)
+			token.Raw = true
 		} else {
-			s.stringLit()
+			token = s.stringLit(false)
 		}
-		token = s.wrapTokenAs(text.StringLit)
 	case s.match('\''):
-		s.charLit()
-		token = s.wrapTokenAs(text.CharLit)
+		token = s.charLit()
 	default:
 		s.advance()
 		token = s.wrapTokenWith(text.ErrorType, s.text())
@@ -360,6 +425,51 @@ func (s *Scanner) next() (token text.Token) {
 	return
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Trivia
+
+// Leading trivia spans lines, while trailing trivia stops before the first line
+// break, which then leads the next token
+func (s *Scanner) trivia(newlines bool) (trivia []text.Token) {
+	for s.keepTrivia && !s.resumeString {
+		switch {
+		case s.match(' ', '\t', text.CR), newlines && s.match(text.LF, text.FF):
+			trivia = append(trivia, s.whitespace(newlines))
+		case s.isCommentStart():
+			trivia = append(trivia, s.comment())
+		default:
+			return
+		}
+	}
+	return
+}
+
+func (s *Scanner) whitespace(newlines bool) text.Token {
+	s.startToken()
+	for s.match(' ', '\t', text.CR) || newlines && s.match(text.LF, text.FF) {
+		s.skipRune()
+	}
+	return s.wrapTokenWith(text.WhitespaceType, s.text())
+}
+
+// Doc comments start with either /// or /**, but neither //// nor /**/
+func (s *Scanner) comment() text.Token {
+	s.startToken()
+	typ := text.CommentType
+	switch {
+	case s.peekAt(1) == '/' && s.peekAt(2) == '/' && s.peekAt(3) != '/',
+		s.peekAt(1) == '*' && s.peekAt(2) == '*' && s.peekAt(3) != '*' && s.peekAt(3) != '/':
+		typ = text.DocCommentType
+	}
+	s.skipRune() // '/'
+	s.skipComment()
+	return s.wrapTokenWith(typ, s.text())
+}
+
+func (s *Scanner) isCommentStart() bool {
+	return s.match('/') && (s.peekAt(1) == '/' || s.peekAt(1) == '*')
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Comments
 
@@ -374,6 +484,8 @@ func (s *Scanner) skipComment() bool {
 }
 
 func (s *Scanner) skipCommentToEnd(isLineComment bool) {
+	s.verbatim = true
+	defer func() { s.verbatim = false }()
 	if isLineComment {
 		s.skipLineComment()
 	} else {
@@ -383,7 +495,7 @@ func (s *Scanner) skipCommentToEnd(isLineComment bool) {
 }
 
 func (s *Scanner) skipLineComment() {
-	for !s.match(text.SU, text.CR, text.LF) {
+	for !s.eof() && !s.match(text.CR, text.LF) {
 		s.skipRune()
 	}
 }
@@ -447,10 +559,8 @@ func (s *Scanner) identOrOperatorRest() {
 
 func (s *Scanner) operatorRest() {
 	switch {
-	case s.accept('/'):
-		if !s.skipComment() {
-			s.ingest('/')
-		}
+	case s.isCommentStart():
+		// a comment right after an operator isn't part of it
 	case s.acceptIf(text.IsOperatorPart):
 		s.operatorRest()
 	case s.acceptIf(text.IsSpecial):
@@ -533,106 +643,187 @@ func (s *Scanner) number() (token text.Token) {
 ////////////////////////////////////////////////////////////////////////////////
 // String, Char & escapes
 
-func (s *Scanner) escape(digits fragments.Fragment, expected int) {
-	seq := text.EscapeBuffer(expected)
-	for n := 0; n < expected; n++ {
-		switch {
-		case s.matchIf(digits):
-			seq[n] = s.peek()
-			s.skipRune()
-		default:
-			s.syntaxError(s.currentPos(), "invalid character in escape sequence (found %q, expected hexadecimal digit)", s.peek())
-			return
-		}
+func (s *Scanner) litRune() {
+	if !s.match('\\') {
+		s.advance()
+		return
+	}
+	r, n, err := text.DecodeEscape(s.source[s.reader.Offset()+1:])
+	if err != nil {
+		pos := s.currentPos()
+		pos.Column += 1 + err.(*text.EscapeError).Offset
+		s.syntaxError(pos, "%s", err.Error())
+	} else {
+		s.ingest(r)
+	}
+	for i := 0; i <= n; i++ { // escapes never span lines
+		s.skipRune()
 	}
-	s.ingest(seq.Rune())
 }
 
-func (s *Scanner) invalidEscape() {
-	s.syntaxError(s.currentPos(), "invalid escape character")
-	s.advance()
+func (s *Scanner) litRunes(del rune) {
+	for !s.match(del) && !s.eof() && !s.match(text.SU, text.CR, text.LF) && !s.isInterpolationStart() {
+		s.litRune()
+	}
 }
 
-func (s *Scanner) litRune() {
+// Strings embed expressions either as ${expr} or $name. Such strings are split
+// into a head, up to the first embedded expression, middles between two of them
+// and a tail, up to the closing quote. The opening brace of ${ stays on the
+// parens stack until its matching brace resumes the string.
+func (s *Scanner) stringLit(continued bool) (token text.Token) {
+	s.verbatim = true
+	defer func() { s.verbatim = false }()
+	s.litRunes('"')
+	typ := text.StringLit
+	if continued {
+		typ = text.StringTailLit
+	}
 	switch {
-	case s.match('\\'):
+	case s.match('"'):
 		s.skipRune()
-		switch s.peek() {
-		case 'b':
-			s.ingest('\b')
-		case 't':
-			s.ingest('\t')
-		case 'n':
-			s.ingest('\n')
-		case 'f':
-			s.ingest('\f')
-		case 'r':
-			s.ingest('\r')
-		case '"':
-			s.ingest('"')
-		case '\'':
-			s.ingest('\'')
-		case '\\':
-			s.ingest('\\')
-		case 'x', 'X':
-			s.skipRune()
-			s.escape(text.HexDigit, 2)
-			return
-		case 'u', 'U':
+	case s.isInterpolationStart():
+		typ = text.StringHeadLit
+		if continued {
+			typ = text.StringMidLit
+		}
+		s.skipRune() // '$'
+		if s.match('{') {
 			s.skipRune()
-			s.escape(text.HexDigit, 4)
+			token = s.wrapTokenAs(typ)
+			s.parens.push(token)
 			return
-		default:
-			s.invalidEscape()
 		}
-		s.skipRune()
+		s.interpolatedIdent = true
 	default:
-		s.advance()
+		s.syntaxError(s.currentPos(), "unclosed string literal")
 	}
+	return s.wrapTokenAs(typ)
 }
 
-func (s *Scanner) litRunes(del rune) {
-	for !s.match(del) && !s.eof() && !s.match(text.SU, text.CR, text.LF) {
-		s.litRune()
+func (s *Scanner) isInterpolationStart() bool {
+	return s.match('$') && (s.peekAt(1) == '{' || s.peekAt(1) != '$' && text.IdentStart(s.peekAt(1)))
+}
+
+// Names interpolated with $name are made of letters, digits and underscores,
+// and stop at the next '$' so that "$x$y" interpolates x then y
+func (s *Scanner) interpolatedName() {
+	for !s.match('$') && (s.acceptIf(text.IdentRest) || s.accept('_')) {
 	}
 }
 
-func (s *Scanner) stringLit() {
-	s.litRunes('"')
-	if s.match('"') {
-		s.skipRune()
+func (s *Scanner) isInterpolation() bool {
+	return !s.parens.isEmpty() && (text.StringHead(s.parens.peek()) || text.StringMid(s.parens.peek()))
+}
+
+// Raw strings are closed by the first three quotes not followed by another one,
+// so that they may end with a quote: """say "hi""""
+func (s *Scanner) rawString() {
+	s.verbatim = true
+	defer func() { s.verbatim = false }()
+	for !s.eof() && !s.isRawStringEnd() {
+		s.advance()
+	}
+	content := s.data()
+	if s.eof() {
+		s.syntaxError(s.pos(), "unclosed raw string literal")
 	} else {
-		s.syntaxError(s.currentPos(), "unclosed stringLit literal")
+		s.skipRune()
+		s.skipRune()
+		s.skipRune()
 	}
+	s.tokenData.Reset()
+	s.tokenData.WriteString(trimIndent(content))
 }
 
-func (s *Scanner) charLit() {
-	s.skipRune()
+func (s *Scanner) isRawStringEnd() bool {
+	return s.match('"') && s.peekAt(1) == '"' && s.peekAt(2) == '"' && s.peekAt(3) != '"'
+}
+
+// The content of multiline raw strings goes without the blank lines right after
+// the opening quotes and right before the closing ones, and without the
+// indentation common to all its other lines
+func trimIndent(content string) string {
+	lines := strings.Split(content, "\n")
+	if len(lines) == 1 {
+		return content
+	}
+	if strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) == "" {
+		lines = lines[:n-1]
+	}
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range lines {
+		if len(line) < indent {
+			lines[i] = ""
+		} else if indent > 0 {
+			lines[i] = line[indent:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// A quote followed by a single code point, possibly escaped, and a closing quote
+// makes a character literal: 'a', '\n'. A name or an operator that isn't closed
+// makes a symbol literal instead: 'name
+func (s *Scanner) charLit() text.Token {
+	s.verbatim = true
+	defer func() { s.verbatim = false }()
+	s.skipRune() // '\''
 	switch {
-	case s.matchIf(text.IsIdentifierStart):
-		s.charLitOr(s.identRest)
+	case s.matchIf(text.IdentStart):
+		return s.charLitOr(s.identRest)
 	case s.matchIf(text.IsOperatorPart) && !s.match('\\'):
-		s.charLitOr(s.operatorRest)
-	case !s.eof() && !s.match(text.SU, text.CR, text.LF):
-		emptyCharLit := s.match('\'')
-		s.litRune()
-		switch {
-		case s.match('\''):
-			if emptyCharLit {
-				s.syntaxError(s.pos(), "empty character literal (use '\\'' for single quote)")
-			} else {
-				s.skipRune()
-			}
-		case emptyCharLit:
+		return s.charLitOr(s.operatorRest)
+	case s.match('\''):
+		s.skipRune()
+		if s.match('\'') {
+			s.skipRune()
+			s.syntaxError(s.pos(), "empty character literal (use '\\'' for single quote)")
+		} else {
 			s.syntaxError(s.pos(), "empty character literal")
-		default:
-			s.syntaxError(s.currentPos(), "unclosed character literal")
 		}
+	case !s.eof() && !s.match(text.CR, text.LF):
+		s.litRune()
+		s.closeCharLit()
 	default:
 		s.syntaxError(s.currentPos(), "unclosed character literal")
 	}
+	return s.wrapTokenAs(text.CharLit)
 }
 
-func (s *Scanner) charLitOr(op func()) {
+func (s *Scanner) charLitOr(rest func()) text.Token {
+	s.advance()
+	if !s.match('\'') {
+		rest()
+		if !s.match('\'') {
+			return s.wrapTokenAs(text.SymbolLit)
+		}
+	}
+	s.closeCharLit()
+	return s.wrapTokenAs(text.CharLit)
+}
 
+func (s *Scanner) closeCharLit() {
+	for !s.eof() && !s.match('\'', text.CR, text.LF) {
+		s.litRune()
+	}
+	if !s.match('\'') {
+		s.syntaxError(s.currentPos(), "unclosed character literal")
+		return
+	}
+	s.skipRune()
+	if len([]rune(s.data())) > 1 {
+		s.syntaxError(s.pos(), "character literal must hold a single code point, found '%s'", s.data())
+	}
 }
@@ -0,0 +1,203 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Spriithy/rosa/pkg/compiler/text"
+)
+
+// scan reads every token of source up to the end of file, which is left out
+func scan(source string, trivia bool) (tokens []text.Token, logs []Log) {
+	s := NewStringScanner("test.rosa", source)
+	if trivia {
+		s.KeepTrivia()
+	}
+	for token := s.Scan(); !text.Eof(token); token = s.Scan() {
+		tokens = append(tokens, token)
+	}
+	return tokens, s.Logs
+}
+
+// Formats tokens as Type "text", separated by spaces
+func formatTokens(tokens []text.Token) string {
+	var formatted []string
+	for _, token := range tokens {
+		formatted = append(formatted, fmt.Sprintf("%s %q", token.Type.Name, token.Text))
+	}
+	return strings.Join(formatted, " ")
+}
+
+func formatLogs(logs []Log) string {
+	var formatted []string
+	for _, log := range logs {
+		formatted = append(formatted, log.AsError().Error())
+	}
+	return strings.Join(formatted, "; ")
+}
+
+type scanTest struct {
+	source string
+	tokens string
+	logs   string
+}
+
+func runScanTests(t *testing.T, tests []scanTest) {
+	t.Helper()
+	for _, test := range tests {
+		tokens, logs := scan(test.source, false)
+		if formatted := formatTokens(tokens); formatted != test.tokens {
+			t.Errorf("scanning %q: got %s, expected %s", test.source, formatted, test.tokens)
+		}
+		if formatted := formatLogs(logs); formatted != test.logs {
+			t.Errorf("scanning %q: logged %q, expected %q", test.source, formatted, test.logs)
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	runScanTests(t, []scanTest{
+		{`"a${x + 1}b"`, `StringHeadLit "a" Identifier "x" Operator "+" IntegerLit "1" StringTailLit "b"`, ""},
+		{`"${ {1} }"`, `StringHeadLit "" { "{" IntegerLit "1" } "}" StringTailLit ""`, ""},
+		{`"$name!"`, `StringHeadLit "" Identifier "name" StringTailLit "!"`, ""},
+		{`"$x$y"`, `StringHeadLit "" Identifier "x" StringMidLit "" Identifier "y" StringTailLit ""`, ""},
+		{`"$x_1 $$"`, `StringHeadLit "" Identifier "x_1" StringTailLit " $$"`, ""},
+		{`"a${"b${c}"}d"`, `StringHeadLit "a" StringHeadLit "b" Identifier "c" StringTailLit "" StringTailLit "d"`, ""},
+		{`"a${x`, `StringHeadLit "a" Identifier "x"`, "test.rosa:1:1: syntax error: unclosed string interpolation"},
+		{`"a$x`, `StringHeadLit "a" Identifier "x" StringTailLit ""`, "test.rosa:1:5: syntax error: unclosed string literal"},
+	})
+}
+
+func TestTrivia(t *testing.T) {
+	tests := []struct {
+		source string
+		tokens []string // as leading trivia | token | trailing trivia
+	}{
+		{"a b\n\n  c", []string{
+			`| Identifier "a" | Whitespace " "`,
+			`| Identifier "b" |`,
+			`Whitespace "\n\n  " | Identifier "c" |`,
+		}},
+		{"/// doc\n// plain\ndef", []string{
+			`DocComment "/// doc" Whitespace "\n" Comment "// plain" Whitespace "\n" | Keyword "def" |`,
+		}},
+		{"/** doc */ /**/ a //// four\n", []string{
+			`DocComment "/** doc */" Whitespace " " Comment "/**/" Whitespace " " | Identifier "a" | Whitespace " " Comment "//// four"`,
+			`Whitespace "\n" | Eof "" |`,
+		}},
+		{"a +// c\n  b /* x */\n", []string{
+			`| Identifier "a" | Whitespace " "`,
+			`| Operator "+" | Comment "// c"`,
+			`Whitespace "\n  " | Identifier "b" | Whitespace " " Comment "/* x */"`,
+			`Whitespace "\n" | Eof "" |`,
+		}},
+		{"a +/* c */b", []string{
+			`| Identifier "a" | Whitespace " "`,
+			`| Operator "+" | Comment "/* c */"`,
+			`| Identifier "b" |`,
+		}},
+	}
+	for _, test := range tests {
+		s := NewStringScanner("test.rosa", test.source)
+		s.KeepTrivia()
+		var tokens []string
+		for token := s.Scan(); ; token = s.Scan() {
+			if text.Eof(token) && len(token.Leading) == 0 {
+				break
+			}
+			tokens = append(tokens, strings.TrimSpace(fmt.Sprintf("%s | %s | %s",
+				formatTokens(token.Leading), formatTokens([]text.Token{token}), formatTokens(token.Trailing))))
+			if text.Eof(token) {
+				break
+			}
+		}
+		if got, expected := strings.Join(tokens, "\n"), strings.Join(test.tokens, "\n"); got != expected {
+			t.Errorf("scanning %q: got\n%s\nexpected\n%s", test.source, got, expected)
+		}
+	}
+}
+
+// Formats tokens as text@line:column, the end of file included
+func formatPositions(source string) string {
+	s := NewStringScanner("test.rosa", source)
+	var formatted []string
+	for token := s.Scan(); ; token = s.Scan() {
+		formatted = append(formatted, fmt.Sprintf("%s@%d:%d", token.Text, token.Pos.Line, token.Pos.Column))
+		if text.Eof(token) {
+			return strings.Join(formatted, " ")
+		}
+	}
+}
+
+func TestRawStrings(t *testing.T) {
+	runScanTests(t, []scanTest{
+		{`"""raw \n ${x}"""`, `StringLit "raw \\n ${x}"`, ""},
+		{"\"\"\"\n    a\n      b\n\n    c\n    \"\"\"", `StringLit "a\n  b\n\nc"`, ""},
+		{"\"\"\"a\n\tb\"\"\"", `StringLit "a\n\tb"`, ""},
+		{"\"\"\"\r\n  x\r\n  \"\"\"", `StringLit "x"`, ""},
+		{`"""say "hi""""`, `StringLit "say \"hi\""`, ""},
+		{`""""""`, `StringLit ""`, ""},
+		{`"""a""" """b"""`, `StringLit "a" StringLit "b"`, ""},
+		{"\"\"\"a\nb", `StringLit "a\nb"`, "test.rosa:1:1: syntax error: unclosed raw string literal"},
+	})
+	tests := []struct {
+		source    string
+		positions string
+	}{
+		{"x \"\"\"a\n  b\"\"\" y\nz", "x@1:1 a\n  b@1:3 y@2:8 z@3:1 @3:2"},
+		{"\"\"\"\r\n  x\r\n  \"\"\" y", "x@1:1 y@3:7 @3:8"},
+	}
+	for _, test := range tests {
+		if positions := formatPositions(test.source); positions != test.positions {
+			t.Errorf("scanning %q: got %q, expected %q", test.source, positions, test.positions)
+		}
+	}
+}
+
+func TestCharAndSymbolLiterals(t *testing.T) {
+	runScanTests(t, []scanTest{
+		{`'a'`, `CharLit "a"`, ""},
+		{`'\n'`, `CharLit "\n"`, ""},
+		{`'\''`, `CharLit "'"`, ""},
+		{`'\u{1F600}'`, `CharLit "😀"`, ""},
+		{`'😀'`, `CharLit "😀"`, ""},
+		{`'ab'`, `CharLit "ab"`, "test.rosa:1:1: syntax error: character literal must hold a single code point, found 'ab'"},
+		{`'\q'`, `CharLit ""`, "test.rosa:1:3: syntax error: invalid escape character 'q'"},
+		{`''`, `CharLit ""`, "test.rosa:1:1: syntax error: empty character literal"},
+		{`'''`, `CharLit ""`, `test.rosa:1:1: syntax error: empty character literal (use '\'' for single quote)`},
+		{`'name`, `SymbolLit "name"`, ""},
+		{`'+`, `SymbolLit "+"`, ""},
+		{`'+= x`, `SymbolLit "+=" Identifier "x"`, ""},
+		{`f 'x 'y'`, `Identifier "f" SymbolLit "x" CharLit "y"`, ""},
+	})
+}
+
+func TestSourcePositions(t *testing.T) {
+	tests := []struct {
+		source    string
+		positions string
+	}{
+		{"a\n\tbb\n\n  c", "a@1:1 bb@2:2 c@4:3 @4:4"},
+		{"a\r\n\tbb\r\n\r\n  c", "a@1:1 bb@2:2 c@4:3 @4:4"},
+		{"a /* \r\n */ b // x\r\nc", "a@1:1 b@2:5 c@3:1 @3:2"},
+		{"x = \"\"\"\r\n  y\r\n  \"\"\" z", "x@1:1 =@1:3 y@1:5 z@3:7 @3:8"},
+		// unicode escapes stand for the code point outside of literals and
+		// comments, positions still counting the runes of the source
+		{`\u0061bc \u002B d`, "abc@1:1 +@1:10 d@1:17 @1:18"},
+		{`// \u000A x`, "@1:12"},
+		{`/* \u002A/ */ z`, "z@1:15 @1:16"},
+		{`\u0022x" y`, "x@1:1 y@1:10 @1:11"},
+		{`"\u0022" x`, `"@1:1 x@1:10 @1:11`},
+		{`'\u0027' x`, "'@1:1 x@1:10 @1:11"},
+	}
+	for _, test := range tests {
+		if positions := formatPositions(test.source); positions != test.positions {
+			t.Errorf("scanning %q: got %q, expected %q", test.source, positions, test.positions)
+		}
+	}
+	_, logs := scan("a\r\n  )", false)
+	if formatted := formatLogs(logs); formatted != "test.rosa:2:3: syntax error: ')' unexpected" {
+		t.Errorf("logged %q on a CRLF line", formatted)
+	}
+}
@@ -0,0 +1,68 @@
+package compiler
+
+import (
+	"github.com/Spriithy/rosa/pkg/compiler/ast"
+	"github.com/Spriithy/rosa/pkg/compiler/text"
+)
+
+// A scope maps the names it binds to whether they are mutable
+type scope map[string]bool
+
+func (p *Parser) openScope() {
+	p.scopes = append(p.scopes, scope{})
+}
+
+func (p *Parser) closeScope() {
+	p.scopes = p.scopes[:len(p.scopes)-1]
+}
+
+// Function bodies open a scope binding their parameters, in which 'return' is
+// allowed
+func (p *Parser) openFunction(params ...[]*ast.ParamAST) {
+	p.openScope()
+	for _, list := range params {
+		for _, param := range list {
+			p.declare(param.Name, false)
+		}
+	}
+	p.functionDepth++
+}
+
+func (p *Parser) closeFunction() {
+	p.functionDepth--
+	p.closeScope()
+}
+
+func (p *Parser) declare(name text.Token, mutable bool) {
+	p.scopes[len(p.scopes)-1][name.Text] = mutable
+}
+
+func (p *Parser) declarePattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.VarPattern:
+		p.declare(pattern.Name, false)
+	case *ast.ConstructorPattern:
+		for _, arg := range pattern.Args {
+			p.declarePattern(arg)
+		}
+	case *ast.TuplePattern:
+		for _, elem := range pattern.Elems {
+			p.declarePattern(elem)
+		}
+	case *ast.ListPattern:
+		for _, elem := range pattern.Elems {
+			p.declarePattern(elem)
+		}
+	}
+}
+
+// Resolves a name to the innermost binding, top level names being bound from the
+// start of the module
+func (p *Parser) lookup(name text.Token) (mutable, found bool) {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if mutable, found = p.scopes[i][name.Text]; found {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,150 @@
+package text
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// EscapeError reports an invalid escape sequence, Offset being the index of the
+// faulty rune, counted from the rune right after the backslash
+type EscapeError struct {
+	Offset  int
+	Message string
+}
+
+func (e *EscapeError) Error() string {
+	return e.Message
+}
+
+func escapeErrorf(offset int, message string, args ...interface{}) *EscapeError {
+	return &EscapeError{
+		Offset:  offset,
+		Message: fmt.Sprintf(message, args...),
+	}
+}
+
+var simpleEscapes = map[rune]rune{
+	'a':  '\a',
+	'b':  '\b',
+	't':  '\t',
+	'n':  '\n',
+	'v':  '\v',
+	'f':  '\f',
+	'r':  '\r',
+	'"':  '"',
+	'\'': '\'',
+	'\\': '\\',
+	'$':  '$',
+}
+
+// DecodeEscape decodes the escape sequence seq starts with, seq being what
+// follows a backslash. It returns the rune the sequence stands for and the
+// number of runes it spans, which are worth skipping even when err is set.
+// Escapes are either one of \a \b \t \n \v \f \r \" \' \\ \$, an octal
+// value up to \377, \xHH, \uHHHH, \UHHHHHHHH or \u{H...} with up to six digits.
+// Octal and \x escapes both stand for the code points up to U+00FF, the others
+// must be valid unicode scalar values.
+func DecodeEscape(seq []rune) (r rune, n int, err error) {
+	if len(seq) == 0 || IsLineBreakRune(seq[0]) {
+		return 0, 0, escapeErrorf(0, "unterminated escape sequence")
+	}
+	if r, ok := simpleEscapes[seq[0]]; ok {
+		return r, 1, nil
+	}
+	var v uint32
+	switch c := seq[0]; {
+	case OctalDigit(c):
+		// \0 to \377, hence only two digits after a leading 4 to 7
+		max := 3
+		if c > '3' {
+			max = 2
+		}
+		for n = 0; n < max && n < len(seq) && OctalDigit(seq[n]); n++ {
+			r = r<<3 | rune(DigitToInt(seq[n], 8))
+		}
+		return
+	case c == 'x', c == 'X':
+		v, n, err = hexEscape(seq, 1, 2)
+		return rune(v), n, err
+	case c == 'u' && len(seq) > 1 && seq[1] == '{':
+		v, n, err = bracedEscape(seq)
+	case c == 'u':
+		v, n, err = hexEscape(seq, 1, 4)
+	case c == 'U':
+		v, n, err = hexEscape(seq, 1, 8)
+	default:
+		return 0, 1, escapeErrorf(0, "invalid escape character %q", c)
+	}
+	if err != nil {
+		return 0, n, err
+	}
+	return scalar(v, seq, n)
+}
+
+// Reads exactly count hexadecimal digits from seq[start:]. Eight digits don't
+// fit in a rune, hence the unsigned value.
+func hexEscape(seq []rune, start, count int) (v uint32, n int, err error) {
+	for n = start; n < start+count; n++ {
+		if n >= len(seq) || !HexDigit(seq[n]) {
+			return 0, n, escapeErrorf(n, "expected %d hexadecimal digits in escape sequence", count)
+		}
+		v = v<<4 | uint32(DigitToInt(seq[n], 16))
+	}
+	return
+}
+
+// \u{1F600}
+func bracedEscape(seq []rune) (v uint32, n int, err error) {
+	for n = 2; n < len(seq) && HexDigit(seq[n]); n++ {
+		if n-2 == 6 {
+			return 0, n, escapeErrorf(n, "too many digits in escape sequence, expected at most 6")
+		}
+		v = v<<4 | uint32(DigitToInt(seq[n], 16))
+	}
+	switch {
+	case n < len(seq) && seq[n] != '}' && !IsLineBreakRune(seq[n]):
+		return 0, n, escapeErrorf(n, "invalid character %q in escape sequence, expected hexadecimal digit", seq[n])
+	case n == 2 && n < len(seq) && seq[n] == '}':
+		return 0, n + 1, escapeErrorf(n, "expected hexadecimal digits in escape sequence")
+	case n >= len(seq) || seq[n] != '}':
+		return 0, n, escapeErrorf(n, "expected '}' to close escape sequence")
+	}
+	return v, n + 1, nil
+}
+
+// Checks the value of a \u or \U escape spanning seq[:n] is a unicode scalar
+// value, reporting errors at its first digit
+func scalar(v uint32, seq []rune, n int) (rune, int, error) {
+	offset := 1
+	if seq[1] == '{' {
+		offset = 2
+	}
+	switch {
+	case 0xD800 <= v && v <= 0xDFFF:
+		return 0, n, escapeErrorf(offset, "escape sequence stands for the surrogate U+%04X, which isn't a valid code point", v)
+	case v > utf8.MaxRune:
+		return 0, n, escapeErrorf(offset, "escape sequence stands for %X, beyond the last code point U+10FFFF", v)
+	}
+	return rune(v), n, nil
+}
+
+// Unescape decodes every escape sequence of str
+func Unescape(str string) (string, error) {
+	var sb strings.Builder
+	runes := []rune(str)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' {
+			sb.WriteRune(runes[i])
+			continue
+		}
+		r, n, err := DecodeEscape(runes[i+1:])
+		if err != nil {
+			err.(*EscapeError).Offset += i + 1
+			return "", err
+		}
+		sb.WriteRune(r)
+		i += n
+	}
+	return sb.String(), nil
+}
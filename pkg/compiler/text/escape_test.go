@@ -0,0 +1,97 @@
+package text
+
+import "testing"
+
+func TestDecodeEscape(t *testing.T) {
+	tests := []struct {
+		seq    string
+		r      rune
+		n      int
+		offset int // of the error, -1 when the escape is valid
+	}{
+		{`n`, '\n', 1, -1},
+		{`$x`, '$', 1, -1},
+		{`0`, 0, 1, -1},
+		{`101`, 'A', 3, -1},
+		{`377`, 0xFF, 3, -1},
+		{`400`, 040, 2, -1},
+		{`777`, 077, 2, -1},
+		{`x41`, 'A', 3, -1},
+		{`x7F`, 0x7F, 3, -1},
+		{`x80`, 0x80, 3, -1},
+		{`xFF`, 0xFF, 3, -1},
+		{`x4`, 0, 2, 2},
+		{`u00e9`, 'é', 5, -1},
+		{`u00G9`, 0, 3, 3},
+		{`uD800`, 0, 5, 1},
+		{`uDFFF`, 0, 5, 1},
+		{`U0001F600`, '😀', 9, -1},
+		{`U0010FFFF`, 0x10FFFF, 9, -1},
+		{`U00110000`, 0, 9, 1},
+		{`U80000000`, 0, 9, 1},
+		{`UFFFFFFFF`, 0, 9, 1},
+		{`U0000D800`, 0, 9, 1},
+		{`U1234`, 0, 5, 5},
+		{`u{41}`, 'A', 5, -1},
+		{`u{1F600}`, '😀', 8, -1},
+		{`u{10FFFF}`, 0x10FFFF, 9, -1},
+		{`u{110000}`, 0, 9, 2},
+		{`u{D800}`, 0, 7, 2},
+		{`u{1234567}`, 0, 8, 8},
+		{`u{}`, 0, 3, 2},
+		{`u{12`, 0, 4, 4},
+		{`u{12x}`, 0, 4, 4},
+		{`q`, 0, 1, 0},
+		{``, 0, 0, 0},
+		{"\n", 0, 0, 0},
+	}
+	for _, test := range tests {
+		r, n, err := DecodeEscape([]rune(test.seq))
+		switch {
+		case test.offset < 0 && err != nil:
+			t.Errorf("\\%s: unexpected error %v", test.seq, err)
+		case test.offset >= 0 && err == nil:
+			t.Errorf("\\%s: expected an error", test.seq)
+		case err != nil && err.(*EscapeError).Offset != test.offset:
+			t.Errorf("\\%s: error at %d, expected %d", test.seq, err.(*EscapeError).Offset, test.offset)
+		case err == nil && r != test.r:
+			t.Errorf("\\%s: decoded %U, expected %U", test.seq, r, test.r)
+		}
+		if n != test.n {
+			t.Errorf("\\%s: spans %d runes, expected %d", test.seq, n, test.n)
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		str    string
+		result string
+		offset int // of the error, -1 when every escape is valid
+	}{
+		{`plain`, "plain", -1},
+		{`a\tb\n`, "a\tb\n", -1},
+		{`\u{e9}té`, "été", -1},
+		{`\101\x42\U00000043`, "ABC", -1},
+		{`\xFF\377\u00FF`, "\u00FF\u00FF\u00FF", -1},
+		{`\$name`, "$name", -1},
+		{`ab\UFFFFFFFF`, "", 4},
+		{`é\uD800`, "", 3},
+		{`ab\u{1234567}`, "", 11},
+		{`\q`, "", 1},
+		{`trailing\`, "", 9},
+	}
+	for _, test := range tests {
+		result, err := Unescape(test.str)
+		switch {
+		case test.offset < 0 && err != nil:
+			t.Errorf("%s: unexpected error %v", test.str, err)
+		case test.offset >= 0 && err == nil:
+			t.Errorf("%s: expected an error", test.str)
+		case err != nil && err.(*EscapeError).Offset != test.offset:
+			t.Errorf("%s: error at %d, expected %d", test.str, err.(*EscapeError).Offset, test.offset)
+		case result != test.result:
+			t.Errorf("%s: unescaped to %q, expected %q", test.str, result, test.result)
+		}
+	}
+}
@@ -21,6 +21,9 @@ type RuneSliceReader struct {
 	// The start offset of the line before the current one
 	lastLineStartOffset int
 
+	// The line of the next rune, starting at 1
+	line int
+
 	// The last index at which an unicode escape was found
 	lastUnicodeOffset int
 
@@ -47,37 +50,97 @@ func FileRuneSliceReader(path string, error func(int, string)) (*RuneSliceReader
 	if err != nil {
 		return nil, fmt.Errorf("error: failed to open %s. %s", path, err.Error())
 	}
+	return StringRuneSliceReader(string(source), error), nil
+}
+
+func StringRuneSliceReader(source string, error func(int, string)) *RuneSliceReader {
 	rsc := NewRuneSliceReader(error)
-	rsc.buf = []rune(string(source))
-	return rsc, nil
+	rsc.buf = []rune(source)
+	return rsc
 }
 
 func NewRuneSliceReader(error func(int, string)) *RuneSliceReader {
 	return &RuneSliceReader{
+		line:              1,
 		lastUnicodeOffset: -1,
 		decodeUni:         func() bool { return true },
 		error:             error,
 	}
 }
 
+// Lookahead copies don't report errors, which are reported once the runes are
+// actually read
 func CopyRuneSliceReader(other *RuneSliceReader, lookahead bool) *RuneSliceReader {
-	return &RuneSliceReader{
+	rsc := &RuneSliceReader{
 		buf:                 other.buf,
 		r:                   other.r,
 		runeOffset:          other.runeOffset,
 		lineStartOffset:     other.lineStartOffset,
 		lastLineStartOffset: other.lastLineStartOffset,
+		line:                other.line,
 		lastUnicodeOffset:   other.lastUnicodeOffset,
 		decodeUni:           other.decodeUni,
 		error:               other.error,
 		lookahead:           lookahead,
 	}
+	if lookahead {
+		rsc.error = func(int, string) {}
+	}
+	return rsc
 }
 
 func LookaheadRuneSliceReader(other *RuneSliceReader) *RuneSliceReader {
 	return CopyRuneSliceReader(other, true)
 }
 
+// DecodeUnicodeIf makes \\uXXXX escapes decoded only when decode holds, for
+// instance outside of literals which decode their own escapes
+func (rsc *RuneSliceReader) DecodeUnicodeIf(decode func() bool) {
+	rsc.decodeUni = decode
+}
+
+// The raw source, unicode escapes and CR;LF pairs included
+func (rsc *RuneSliceReader) Source() []rune {
+	return rsc.buf
+}
+
+// The offset of the next rune in the raw source
+func (rsc *RuneSliceReader) Offset() int {
+	return rsc.runeOffset
+}
+
+// The line of the next rune
+func (rsc *RuneSliceReader) Line() int {
+	return rsc.line
+}
+
+// The column of the next rune, as an offset in the raw source from the start of
+// its line
+func (rsc *RuneSliceReader) Column() int {
+	return rsc.runeOffset - rsc.lineStartOffset + 1
+}
+
+// The column of a raw source offset on the current line
+func (rsc *RuneSliceReader) ColumnOf(offset int) int {
+	return offset - rsc.lineStartOffset + 1
+}
+
+func (rsc *RuneSliceReader) EOF() bool {
+	return rsc.runeOffset >= len(rsc.buf)
+}
+
+// Peek returns the rune n runes past the next one, without reading any. The
+// lookahead is a mere copy of the reader, so it costs no allocation.
+func (rsc *RuneSliceReader) Peek(n int) rune {
+	lookahead := *rsc
+	lookahead.lookahead = true
+	lookahead.error = func(int, string) {}
+	for ; n > 0; n-- {
+		lookahead.NextRune()
+	}
+	return lookahead.NextRune()
+}
+
 // Is last character a unicode escape ?
 func (rsc *RuneSliceReader) IsUnicodeEscape() bool {
 	return rsc.runeOffset == rsc.lastUnicodeOffset
@@ -121,24 +184,33 @@ func (rsc *RuneSliceReader) potentialUnicode() {
 		for p >= 0 && rsc.buf[p] == '\\' {
 			p--
 		}
-		return (rsc.runeOffset - p%2) == 0
+		return (rsc.runeOffset-p)%2 == 0
 	}
 
+	// only the first bad digit is reported
+	failed := false
 	udigit := func() rune {
+		if failed {
+			return 0
+		}
 		if rsc.runeOffset >= len(rsc.buf) {
+			failed = true
 			rsc.error(rsc.runeOffset-1, "incomplete unicode escape")
-			return SU
+			return 0
 		}
 		d := DigitToInt(rsc.buf[rsc.runeOffset], 16)
-		if d > 0 {
-			rsc.runeOffset++
-		} else {
+		if d < 0 {
+			failed = true
 			rsc.error(rsc.runeOffset, "error in unicode escape")
+			return 0
 		}
+		rsc.runeOffset++
 		return rune(d)
 	}
 
-	if rsc.runeOffset < len(rsc.buf) && rsc.buf[rsc.runeOffset] == 'u' && rsc.decodeUni() && evenSlashPrefix() {
+	// \\u{...} escapes are left to literals
+	isBraced := rsc.runeOffset+1 < len(rsc.buf) && rsc.buf[rsc.runeOffset+1] == '{'
+	if rsc.runeOffset < len(rsc.buf) && rsc.buf[rsc.runeOffset] == 'u' && !isBraced && rsc.decodeUni() && evenSlashPrefix() {
 		for ok := true; ok; ok = rsc.runeOffset < len(rsc.buf) && rsc.buf[rsc.runeOffset] == 'u' {
 			rsc.runeOffset++
 		}
@@ -171,6 +243,7 @@ func (rsc *RuneSliceReader) potentialLineEnd() {
 	if rsc.r == LF || rsc.r == FF {
 		rsc.lastLineStartOffset = rsc.lineStartOffset
 		rsc.lineStartOffset = rsc.runeOffset
+		rsc.line++
 	}
 }
 
@@ -1,6 +1,7 @@
 package text
 
 import (
+	"fmt"
 	"unicode"
 
 	"github.com/Spriithy/rosa/pkg/compiler/fragments"
@@ -13,11 +14,9 @@ const (
 	SU = '\u001A'
 )
 
-type Escape []rune
-
 func DigitToInt(r rune, base int) (val int) {
 	switch {
-	case r < '9':
+	case '0' <= r && r <= '9':
 		val = int(r - '0')
 	case 'a' <= r && r <= 'z':
 		val = int(r - 'a' + 10)
@@ -32,37 +31,12 @@ func DigitToInt(r rune, base int) (val int) {
 	return
 }
 
-func EscapeBuffer(n int) Escape {
-	return make(Escape, n)
-}
-
-func (e Escape) Rune() (r rune) {
-	for i, d := range e {
-		r |= rune(DigitToInt(d, 16)) << ((len(e) - i - 1) * 4)
-	}
-	return
-}
-
-func (e Escape) String() string {
-	return "\\u" + string(e)
-}
-
+// EscapeRune spells r as the escape sequence DecodeEscape reads back
 func EscapeRune(r rune) string {
-	hex := func(ch rune) (r rune) {
-		if ch < 10 {
-			r = '0'
-		} else {
-			r = 'A'
-		}
-		r += ch
-		return
+	if r > 0xFFFF {
+		return fmt.Sprintf("\\U%08X", r)
 	}
-	seq := EscapeBuffer(4)
-	seq[0] = hex(r >> 12)
-	seq[1] = hex((r >> 8) % 16)
-	seq[2] = hex((r >> 4) % 16)
-	seq[3] = hex((r >> 0) % 16)
-	return string(seq)
+	return fmt.Sprintf("\\u%04X", r)
 }
 
 func IsLineBreakRune(r rune) bool {
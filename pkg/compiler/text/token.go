@@ -44,10 +44,17 @@ func (t TokenType) String() string {
 }
 
 type Token struct {
-	Type  TokenType
-	Text  string
-	Spans int
+	Type   TokenType
+	Text   string
+	Offset int  // offset of the first rune of the token in the source
+	Spans  int  // offset right past the last rune of the token
+	Raw    bool // string literals taken as is, written between triple quotes
 	Pos
+
+	// Whitespace and comments around the token, only kept by scanners in
+	// trivia mode. Trailing trivia stops at the end of the token's line.
+	Leading  []Token
+	Trailing []Token
 }
 
 func (t Token) String() string {
@@ -66,7 +73,11 @@ var (
 	IntegerLit     = registerTokenType("IntegerLit", "an integer")
 	FloatLit       = registerTokenType("FloatLit", "a float")
 	CharLit        = registerTokenType("CharLit", "a character literal")
+	SymbolLit      = registerTokenType("SymbolLit", "a symbol literal")
 	StringLit      = registerTokenType("StringLit", "a string literal")
+	StringHeadLit  = registerTokenType("StringHeadLit", "the start of an interpolated string")
+	StringMidLit   = registerTokenType("StringMidLit", "the middle of an interpolated string")
+	StringTailLit  = registerTokenType("StringTailLit", "the end of an interpolated string")
 	SeparatorType  = registerTokenType("Separator", "a separator")
 	OperatorType   = registerTokenType("Operator", "an operator")
 
@@ -82,6 +93,12 @@ var (
 	SemicolonType = registerCharToken(';')
 	CommaType     = registerCharToken(',')
 	DotType       = registerCharToken('.')
+
+	// Trivia is never looked up by its text, hence isn't registered
+
+	WhitespaceType = TokenType{Name: "Whitespace", Paraphrase: "whitespace"}
+	CommentType    = TokenType{Name: "Comment", Paraphrase: "a comment"}
+	DocCommentType = TokenType{Name: "DocComment", Paraphrase: "a documentation comment"}
 )
 
 func registerCharToken(r rune) TokenType {
@@ -164,26 +181,47 @@ var (
 	Integer    = tokenOf(IntegerLit)
 	Float      = tokenOf(FloatLit)
 	Char       = tokenOf(CharLit)
+	Symbol     = tokenOf(SymbolLit)
 	String     = tokenOf(StringLit)
+	StringHead = tokenOf(StringHeadLit)
+	StringMid  = tokenOf(StringMidLit)
+	StringTail = tokenOf(StringTailLit)
+	Operator   = tokenOf(OperatorType)
+	Underscore = typedText(IdentifierType, "_")
+
+	Whitespace = tokenOf(WhitespaceType)
+	Comment    = tokenOf(CommentType)
+	DocComment = tokenOf(DocCommentType)
+	Trivia     = anyOf(Whitespace, Comment, DocComment)
 
 	Module  = keyword("module")
 	Import  = keyword("import")
+	As      = keyword("as")
 	Trait   = keyword("trait")
+	Impl    = keyword("impl")
 	Struct  = keyword("struct")
 	Type    = keyword("type")
 	Def     = keyword("def")
 	Let     = keyword("let")
-	Decl    = anyOf(Type, Def, Let)
+	Infix   = keyword("infix")
+	Infixl  = keyword("infixl")
+	Infixr  = keyword("infixr")
+	Fixity  = anyOf(Infix, Infixl, Infixr)
+	Decl    = anyOf(Type, Def, Let, Struct, Trait, Impl, Fixity)
 	Mut     = keyword("mut")
 	Return  = keyword("return")
 	Match   = keyword("match")
 	Case    = keyword("case")
+	If      = keyword("if")
+	Then    = keyword("then")
+	Else    = keyword("else")
 	True    = keyword("true")
 	False   = keyword("false")
 	Boolean = anyOf(True, False)
-	Literal = anyOf(Integer, Float, String, Char, Boolean)
+	Literal = anyOf(Integer, Float, String, Char, Symbol, Boolean)
 
 	Comma     = tokenOf(CommaType)
+	Dot       = tokenOf(DotType)
 	Colon     = tokenOf(ColonType)
 	Semicolon = tokenOf(SemicolonType)
 	Lpar      = tokenOf(LparType)
@@ -193,29 +231,32 @@ var (
 	Lbrc      = tokenOf(LbrcType)
 	Rbrc      = tokenOf(RbrcType)
 
-	Arrow    = op("=>")
-	Dot      = op(".")
-	Plus     = op("+")
-	Inc      = op("++")
-	Minus    = op("-")
-	Dec      = op("--")
-	Star     = op("*")
-	Div      = op("/")
-	And      = op("&")
-	Land     = op("&&")
-	Or       = op("|")
-	Lor      = op("||")
-	Xor      = op("^")
-	Not      = op("~")
-	Lnot     = op("!")
-	Lt       = op("<")
-	Lte      = op("<=")
-	Gt       = op(">")
-	Gte      = op(">=")
-	Eq       = op("==")
-	Neq      = op("!=")
-	Assign   = op("=")
-	Walrus   = op(":=")
-	BinaryOp = anyOf(Plus, Minus, Star, Div, And, Or, Land, Lor, Xor, Lt, Lte, Gt, Gte)
-	UnaryOp  = anyOf(Minus, Inc, Dec, Not, Lnot)
+	Arrow   = op("=>")
+	Plus    = op("+")
+	Inc     = op("++")
+	Minus   = op("-")
+	Dec     = op("--")
+	Star    = op("*")
+	Div     = op("/")
+	Mod     = op("%")
+	And     = op("&")
+	Land    = op("&&")
+	Or      = op("|")
+	Lor     = op("||")
+	Xor     = op("^")
+	Not     = op("~")
+	Lnot    = op("!")
+	Lt      = op("<")
+	Lte     = op("<=")
+	Gt      = op(">")
+	Gte     = op(">=")
+	Eq      = op("==")
+	Neq     = op("!=")
+	Assign  = op("=")
+	Walrus  = op(":=")
+	UnaryOp = anyOf(Minus, Inc, Dec, Not, Lnot)
+
+	// Operators that are part of the syntax, and may not be used as binary
+	// operators in expressions
+	ReservedOp = anyOf(Assign, Arrow, Walrus)
 )